@@ -6,16 +6,25 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/answerlayer/rlmkit/internal/agent"
+	"github.com/answerlayer/rlmkit/internal/checkpoint"
 	"github.com/answerlayer/rlmkit/internal/coding"
 	"github.com/answerlayer/rlmkit/internal/llm/openai"
+	"github.com/answerlayer/rlmkit/internal/mcp"
+	"github.com/answerlayer/rlmkit/internal/repomap"
+	"github.com/answerlayer/rlmkit/internal/sandbox"
 	"github.com/answerlayer/rlmkit/internal/session"
 	"github.com/answerlayer/rlmkit/internal/tools/builtin"
 	"github.com/answerlayer/rlmkit/internal/tools/core"
@@ -38,10 +47,15 @@ type FileConfig struct {
 	MaxToolConcurrency int64    `json:"max_tool_concurrency"`
 	ToolTimeoutSec     int      `json:"tool_timeout_sec"`
 	Stream             bool     `json:"stream"`
+	EnableGitCommit    bool     `json:"enable_git_commit"`
+	GitCommitHooks     bool     `json:"git_commit_hooks"`
+	EnableRunTests     bool     `json:"enable_run_tests"`
 	EnableRunCommand   bool     `json:"enable_run_command"`
 	AllowCommandPrefix []string `json:"allow_command_prefix"`
 	EnableBash         bool     `json:"enable_bash"`
 	AllowBashPrefix    []string `json:"allow_bash_prefix"`
+	AllowShellRedirect bool     `json:"allow_shell_redirects"`
+	AllowShellSubshell bool     `json:"allow_shell_subshells"`
 	EnableHTTPGet      bool     `json:"enable_http_get"`
 	AllowURLPrefix     []string `json:"allow_url_prefix"`
 	EnableDuckDB       bool     `json:"enable_duckdb"`
@@ -50,9 +64,53 @@ type FileConfig struct {
 	BraveAPIKey        string   `json:"brave_api_key"`
 	AllowSearchDomain  []string `json:"allow_search_domain"`
 	WebSearchMaxResult int      `json:"web_search_max_results"`
+	DisableCheckpoints bool     `json:"disable_checkpoints"`
+	ProtectedPaths     []string `json:"protected_paths"`
+	AutoDiagnostics    bool     `json:"auto_diagnostics"`
+	RepoMap            bool     `json:"repo_map"`
+	RepoMapTokens      int      `json:"repo_map_tokens"`
+	// ToolProtocol is "native" (the tools field, default) or "text" (tools
+	// described in the prompt and parsed from replies, for servers without
+	// function calling).
+	ToolProtocol string `json:"tool_protocol"`
+	// Vision means the model accepts images: read_file returns png/jpeg
+	// files as images.
+	Vision bool `json:"vision"`
+	// ShowReasoning prints the model's reasoning (--show-reasoning);
+	// StoreReasoning keeps it in the session's turn records.
+	// PrefilledThink is for chat templates that open the <think> block in
+	// the prompt: text before a lone </think> is then reasoning.
+	ShowReasoning  bool `json:"show_reasoning"`
+	StoreReasoning bool `json:"store_reasoning"`
+	PrefilledThink bool `json:"prefilled_think"`
+	// Steer sends lines typed during a chat or code turn into that turn
+	// (--steer) instead of holding them for the next prompt.
+	Steer bool `json:"steer"`
+	// ConstrainToolArgs (text protocol only), AnswerSchema and GrammarFile
+	// ask the server to constrain generation; see docs/tools.md.
+	ConstrainToolArgs bool            `json:"constrain_tool_args"`
+	AnswerSchema      json.RawMessage `json:"answer_schema"`
+	GrammarFile       string          `json:"grammar_file"`
+	// OutputSchema is a JSON schema file the final answer must match
+	// (--output-schema); OutputRetries bounds the re-prompts (default 2).
+	OutputSchema  string `json:"output_schema"`
+	OutputRetries int    `json:"output_retries"`
+	// Plugins are external executable tools; EnablePluginDir also loads the
+	// executables in .rlmkit/tools/ (off by default: they come with the repo).
+	Plugins         []builtin.PluginConfig `json:"plugins"`
+	EnablePluginDir bool                   `json:"enable_plugin_dir"`
+	// MCPServers maps a server name to its MCP connection settings; the
+	// server's tools are registered as "<name>__<tool>".
+	MCPServers map[string]mcp.ServerConfig `json:"mcp_servers"`
+	// Sandbox holds per-tool sandbox settings keyed by tool name ("bash", "run_command", "run_tests", plugin names).
+	Sandbox map[string]sandbox.Config `json:"sandbox"`
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == sandbox.HelperArg {
+		sandbox.RunHelper(os.Args[2:])
+		return
+	}
 	if len(os.Args) > 1 && (os.Args[1] == "help" || os.Args[1] == "--help" || os.Args[1] == "-h") {
 		usage()
 		return
@@ -75,6 +133,14 @@ func main() {
 		runTools(os.Args[2:])
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "mcp-serve" {
+		runMCPServe(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "checkpoints" {
+		runCheckpoints(os.Args[2:])
+		return
+	}
 
 	runOneShot(os.Args[1:])
 }
@@ -87,6 +153,9 @@ func usage() {
 	fmt.Println("  rlmkit code [flags]          Interactive coding mode (more opinionated prompt)")
 	fmt.Println("  rlmkit -p \"...\" [flags]      One-shot prompt")
 	fmt.Println("  rlmkit tools [flags]         Print available tools as JSON")
+	fmt.Println("  rlmkit mcp-serve [flags]     Serve tools over MCP on stdio (--tool <name> to limit)")
+	fmt.Println("  rlmkit checkpoints list|restore <id> [flags]")
+	fmt.Println("                               List or restore working-tree checkpoints")
 	fmt.Println("  rlmkit version               Print version info")
 	fmt.Println("")
 	fmt.Println("Common flags:")
@@ -98,6 +167,15 @@ func usage() {
 	fmt.Println("  --session-id <id>            Resume or pin a session ID")
 	fmt.Println("  --recent-turns <n>           Number of recent turns to include (default 2)")
 	fmt.Println("  --stream                     Stream model output (default true)")
+	fmt.Println("  --show-reasoning             Print the model's reasoning to stderr (hidden by default)")
+	fmt.Println("  --output-schema <file>       With -p: answer must be JSON matching the schema; printed as JSON")
+	fmt.Println("  --image <path>               With -p: attach an image (repeatable)")
+	fmt.Println("  --steer                      In chat/code: lines typed during a turn steer it")
+	fmt.Println("")
+	fmt.Println("Chat commands:")
+	fmt.Println("  /undo                        Restore the repo to before the last turn's edits")
+	fmt.Println("  /image <path>                Attach an image to your next message")
+	fmt.Println("  Ctrl-C                       Interrupt the running turn (at the prompt: exit)")
 	fmt.Println("")
 	fmt.Println("Safety flags:")
 	fmt.Println("  --enable-run-command         Enable run_command tool (disabled by default)")
@@ -144,6 +222,8 @@ func runChat(args []string) {
 		sessionID   = fs.String("session-id", "", "session id")
 		recentTurns = fs.Int("recent-turns", 0, "recent turns to include (0 uses config/default)")
 		stream      = fs.Bool("stream", true, "stream model output")
+		showReason  = fs.Bool("show-reasoning", false, "print the model's reasoning to stderr")
+		steer       = fs.Bool("steer", false, "send lines typed during a turn to the running turn")
 		enableRun   = fs.Bool("enable-run-command", false, "enable run_command tool")
 		allowPrefix multiStringFlag
 		enableBash  = fs.Bool("enable-bash", false, "enable bash tool")
@@ -165,6 +245,12 @@ func runChat(args []string) {
 
 	cfg := resolveConfig(*configPath, *baseURL, *apiKey, *model, *repoRoot, *sessionDir, *recentTurns, *enableRun, allowPrefix)
 	cfg.Stream = *stream
+	if *showReason {
+		cfg.ShowReasoning = true
+	}
+	if *steer {
+		cfg.Steer = true
+	}
 	if *enableBash {
 		cfg.EnableBash = true
 	}
@@ -210,46 +296,67 @@ func runChat(args []string) {
 	fmt.Printf("session: %s\n", sid)
 	fmt.Println("type 'exit' to quit")
 
-	sc := bufio.NewScanner(os.Stdin)
+	var images []openai.ContentPart // attached with /image, sent with the next message
+	con := newConsole()
+	chatConsole = con
 	for {
 		fmt.Print("> ")
-		if !sc.Scan() {
+		line, ok := con.read()
+		if !ok {
 			break
 		}
-		line := strings.TrimSpace(sc.Text())
+		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 		if line == "exit" || line == "quit" {
 			break
 		}
+		if line == "/undo" {
+			undoLastTurn(cfg, store, sid)
+			continue
+		}
+		if path, ok := strings.CutPrefix(line, "/image "); ok {
+			images = attachImage(images, strings.TrimSpace(path))
+			continue
+		}
 
-		ctx := context.Background()
+		ctx := con.beginTurn(steerer(cfg, eng))
+		attached := images
+		images = nil
 		if cfg.Stream {
-			evCh, errCh := eng.RunStream(ctx, sid, line)
+			evCh, errCh := eng.RunStream(ctx, sid, line, attached...)
+			thinking := reasoningOutput{show: cfg.ShowReasoning}
 			for ev := range evCh {
 				switch ev.Type {
+				case agent.EventReasoningDelta:
+					thinking.delta(ev.Text)
 				case agent.EventAssistantDelta:
+					thinking.end()
 					fmt.Print(ev.Text)
 				case agent.EventToolStart:
+					thinking.end()
 					fmt.Fprintf(os.Stderr, "\n[tool] %s\n", ev.ToolName)
 				case agent.EventToolEnd:
 					fmt.Fprintf(os.Stderr, "[tool done] %s\n", ev.ToolName)
 				case agent.EventFinal:
 				}
 			}
-			if err := <-errCh; err != nil {
-				fmt.Fprintln(os.Stderr, "error:", err)
-			}
+			err := <-errCh
 			fmt.Println("")
+			if err != nil {
+				printTurnError(err)
+			}
 		} else {
-			res, err := eng.Run(ctx, sid, line)
+			res, err := eng.Run(ctx, sid, line, attached...)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "error:", err)
-				continue
+				printTurnError(err)
+			} else {
+				printReasoning(cfg, res.Reasoning)
+				fmt.Println(res.Reply)
 			}
-			fmt.Println(res.Reply)
 		}
+		con.endTurn()
 	}
 }
 
@@ -266,6 +373,8 @@ func runCode(args []string) {
 		sessionID   = fs.String("session-id", "", "session id")
 		recentTurns = fs.Int("recent-turns", 0, "recent turns to include (0 uses config/default)")
 		stream      = fs.Bool("stream", true, "stream model output")
+		showReason  = fs.Bool("show-reasoning", false, "print the model's reasoning to stderr")
+		steer       = fs.Bool("steer", false, "send lines typed during a turn to the running turn")
 		enableRun   = fs.Bool("enable-run-command", false, "enable run_command tool")
 		allowPrefix multiStringFlag
 		enableBash  = fs.Bool("enable-bash", false, "enable bash tool")
@@ -287,6 +396,12 @@ func runCode(args []string) {
 
 	cfg := resolveConfig(*configPath, *baseURL, *apiKey, *model, *repoRoot, *sessionDir, *recentTurns, *enableRun, allowPrefix)
 	cfg.Stream = *stream
+	if *showReason {
+		cfg.ShowReasoning = true
+	}
+	if *steer {
+		cfg.Steer = true
+	}
 	if *enableBash {
 		cfg.EnableBash = true
 	}
@@ -332,46 +447,67 @@ func runCode(args []string) {
 	fmt.Printf("session: %s\n", sid)
 	fmt.Println("type 'exit' to quit")
 
-	sc := bufio.NewScanner(os.Stdin)
+	var images []openai.ContentPart // attached with /image, sent with the next message
+	con := newConsole()
+	chatConsole = con
 	for {
 		fmt.Print("> ")
-		if !sc.Scan() {
+		line, ok := con.read()
+		if !ok {
 			break
 		}
-		line := strings.TrimSpace(sc.Text())
+		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 		if line == "exit" || line == "quit" {
 			break
 		}
+		if line == "/undo" {
+			undoLastTurn(cfg, store, sid)
+			continue
+		}
+		if path, ok := strings.CutPrefix(line, "/image "); ok {
+			images = attachImage(images, strings.TrimSpace(path))
+			continue
+		}
 
-		ctx := context.Background()
+		ctx := con.beginTurn(steerer(cfg, eng))
+		attached := images
+		images = nil
 		if cfg.Stream {
-			evCh, errCh := eng.RunStream(ctx, sid, line)
+			evCh, errCh := eng.RunStream(ctx, sid, line, attached...)
+			thinking := reasoningOutput{show: cfg.ShowReasoning}
 			for ev := range evCh {
 				switch ev.Type {
+				case agent.EventReasoningDelta:
+					thinking.delta(ev.Text)
 				case agent.EventAssistantDelta:
+					thinking.end()
 					fmt.Print(ev.Text)
 				case agent.EventToolStart:
+					thinking.end()
 					fmt.Fprintf(os.Stderr, "\n[tool] %s\n", ev.ToolName)
 				case agent.EventToolEnd:
 					fmt.Fprintf(os.Stderr, "[tool done] %s\n", ev.ToolName)
 				case agent.EventFinal:
 				}
 			}
-			if err := <-errCh; err != nil {
-				fmt.Fprintln(os.Stderr, "error:", err)
-			}
+			err := <-errCh
 			fmt.Println("")
+			if err != nil {
+				printTurnError(err)
+			}
 		} else {
-			res, err := eng.Run(ctx, sid, line)
+			res, err := eng.Run(ctx, sid, line, attached...)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "error:", err)
-				continue
+				printTurnError(err)
+			} else {
+				printReasoning(cfg, res.Reasoning)
+				fmt.Println(res.Reply)
 			}
-			fmt.Println(res.Reply)
 		}
+		con.endTurn()
 	}
 }
 
@@ -388,6 +524,7 @@ func runOneShot(args []string) {
 		sessionID   = fs.String("session-id", "", "session id")
 		recentTurns = fs.Int("recent-turns", 0, "recent turns to include (0 uses config/default)")
 		stream      = fs.Bool("stream", true, "stream model output")
+		showReason  = fs.Bool("show-reasoning", false, "print the model's reasoning to stderr")
 		enableRun   = fs.Bool("enable-run-command", false, "enable run_command tool")
 		allowPrefix multiStringFlag
 		enableBash  = fs.Bool("enable-bash", false, "enable bash tool")
@@ -400,20 +537,36 @@ func runOneShot(args []string) {
 		braveKey    = fs.String("brave-api-key", "", "Brave API key")
 		allowDomain multiStringFlag
 		webMax      = fs.Int("web-search-max-results", 0, "max search results")
+		outSchema   = fs.String("output-schema", "", "JSON schema file the final answer must match; prints the answer as JSON")
+		outRetries  = fs.Int("output-retries", 0, "re-prompts for an answer that does not match --output-schema (default 2)")
+		imagePaths  multiStringFlag
 	)
 	fs.Var(&allowPrefix, "allow-cmd-prefix", "allowlisted command prefix (repeatable)")
 	fs.Var(&allowBash, "allow-bash-prefix", "allowlisted bash script prefix (repeatable)")
 	fs.Var(&allowURL, "allow-url-prefix", "allowlisted URL prefix (repeatable)")
 	fs.Var(&allowDomain, "allow-search-domain", "allowlisted search domain (repeatable)")
+	fs.Var(&imagePaths, "image", "image file to attach to the prompt (repeatable)")
 	_ = fs.Parse(args)
 
 	if *prompt == "" {
 		usage()
 		os.Exit(2)
 	}
+	var images []openai.ContentPart
+	for _, p := range imagePaths {
+		img, err := openai.ImageFilePart(p)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		images = append(images, img)
+	}
 
 	cfg := resolveConfig(*configPath, *baseURL, *apiKey, *model, *repoRoot, *sessionDir, *recentTurns, *enableRun, allowPrefix)
 	cfg.Stream = *stream
+	if *showReason {
+		cfg.ShowReasoning = true
+	}
 	if *enableBash {
 		cfg.EnableBash = true
 	}
@@ -444,6 +597,12 @@ func runOneShot(args []string) {
 	if *webMax > 0 {
 		cfg.WebSearchMaxResult = *webMax
 	}
+	if *outSchema != "" {
+		cfg.OutputSchema = *outSchema
+	}
+	if *outRetries > 0 {
+		cfg.OutputRetries = *outRetries
+	}
 	sid := *sessionID
 	if sid == "" {
 		sid = newSessionID()
@@ -457,13 +616,32 @@ func runOneShot(args []string) {
 	_ = store.EnsureDir()
 
 	ctx := context.Background()
+	if cfg.OutputSchema != "" {
+		// Only the JSON answer goes to stdout, so the output can be piped.
+		res, err := eng.Run(ctx, sid, *prompt, images...)
+		if err != nil {
+			if errors.Is(err, agent.ErrOutputSchema) {
+				fmt.Fprintln(os.Stderr, res.Reply)
+			}
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		b, _ := json.Marshal(res.Output)
+		fmt.Println(string(b))
+		return
+	}
 	if cfg.Stream {
-		evCh, errCh := eng.RunStream(ctx, sid, *prompt)
+		evCh, errCh := eng.RunStream(ctx, sid, *prompt, images...)
+		thinking := reasoningOutput{show: cfg.ShowReasoning}
 		for ev := range evCh {
 			switch ev.Type {
+			case agent.EventReasoningDelta:
+				thinking.delta(ev.Text)
 			case agent.EventAssistantDelta:
+				thinking.end()
 				fmt.Print(ev.Text)
 			case agent.EventToolStart:
+				thinking.end()
 				fmt.Fprintf(os.Stderr, "\n[tool] %s\n", ev.ToolName)
 			case agent.EventToolEnd:
 				fmt.Fprintf(os.Stderr, "[tool done] %s\n", ev.ToolName)
@@ -476,11 +654,12 @@ func runOneShot(args []string) {
 		}
 		fmt.Println("")
 	} else {
-		res, err := eng.Run(ctx, sid, *prompt)
+		res, err := eng.Run(ctx, sid, *prompt, images...)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "error:", err)
 			os.Exit(1)
 		}
+		printReasoning(cfg, res.Reasoning)
 		fmt.Println(res.Reply)
 	}
 }
@@ -560,6 +739,106 @@ func runTools(args []string) {
 	fmt.Println(string(b))
 }
 
+// runMCPServe serves the built-in tools over MCP on stdin/stdout, so other
+// agents and editors can use them. Stdout carries only protocol messages;
+// diagnostics go to stderr.
+func runMCPServe(args []string) {
+	fs := flag.NewFlagSet("mcp-serve", flag.ExitOnError)
+	var (
+		configPath = fs.String("config", "", "config file path (default ./rlmkit.json if present)")
+		repoRoot   = fs.String("repo-root", "", "repo root")
+		sessionDir = fs.String("session-dir", "", "session dir")
+		sessionID  = fs.String("session-id", "", "session read by get_session_context (omit to leave the tool out)")
+		enableRun  = fs.Bool("enable-run-command", false, "enable run_command tool")
+		allowCmd   multiStringFlag
+		enableBash = fs.Bool("enable-bash", false, "enable bash tool")
+		allowBash  multiStringFlag
+		enableHTTP = fs.Bool("enable-http-get", false, "enable http_get tool")
+		allowURL   multiStringFlag
+		enableDuck = fs.Bool("enable-duckdb", false, "enable duckdb_query tool")
+		enableWeb  = fs.Bool("enable-web-search", false, "enable web_search tool")
+		webProv    = fs.String("web-search-provider", "", "search provider (default brave)")
+		braveKey   = fs.String("brave-api-key", "", "Brave API key")
+		allowDom   multiStringFlag
+		webMax     = fs.Int("web-search-max-results", 0, "max search results")
+		only       multiStringFlag
+	)
+	fs.Var(&allowCmd, "allow-cmd-prefix", "allowlisted command prefix (repeatable)")
+	fs.Var(&allowBash, "allow-bash-prefix", "allowlisted bash script prefix (repeatable)")
+	fs.Var(&allowURL, "allow-url-prefix", "allowlisted URL prefix (repeatable)")
+	fs.Var(&allowDom, "allow-search-domain", "allowlisted search domain (repeatable)")
+	fs.Var(&only, "tool", "expose only this tool (repeatable)")
+	_ = fs.Parse(args)
+
+	cfg := resolveConfig(*configPath, "", "", "", *repoRoot, *sessionDir, 0, *enableRun, allowCmd)
+	if *enableBash {
+		cfg.EnableBash = true
+	}
+	if len(allowBash) > 0 {
+		cfg.AllowBashPrefix = allowBash
+	}
+	if *enableHTTP {
+		cfg.EnableHTTPGet = true
+	}
+	if len(allowURL) > 0 {
+		cfg.AllowURLPrefix = allowURL
+	}
+	if *enableDuck {
+		cfg.EnableDuckDB = true
+	}
+	if *enableWeb {
+		cfg.EnableWebSearch = true
+	}
+	if *webProv != "" {
+		cfg.WebSearchProvider = *webProv
+	}
+	if *braveKey != "" {
+		cfg.BraveAPIKey = *braveKey
+	}
+	if len(allowDom) > 0 {
+		cfg.AllowSearchDomain = allowDom
+	}
+	if *webMax > 0 {
+		cfg.WebSearchMaxResult = *webMax
+	}
+	// Re-exporting remote MCP tools could make two servers call each other.
+	cfg.MCPServers = nil
+
+	all, _, err := buildTools(cfg, *sessionID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	want := map[string]bool{}
+	for _, name := range only {
+		want[name] = true
+	}
+	tools := core.NewRegistry()
+	for _, t := range all.All() {
+		// ask_user needs a person at the terminal, which an MCP host is not.
+		if t.Name() == "ask_user" {
+			continue
+		}
+		if len(want) > 0 && !want[t.Name()] {
+			continue
+		}
+		tools.Register(t)
+	}
+	for _, name := range only {
+		if _, ok := tools.Get(name); !ok {
+			fmt.Fprintf(os.Stderr, "warning: --tool %q matches no available tool\n", name)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := mcp.NewServer(tools, mcp.Implementation{Name: "rlmkit", Version: version}, time.Duration(cfg.ToolTimeoutSec)*time.Second)
+	if err := srv.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
 func resolveConfig(configPath, baseURL, apiKey, model, repoRoot, sessionDir string, recentTurns int, enableRun bool, allowPrefix []string) FileConfig {
 	fc, ok, err := loadFileConfig(configPath)
 	if err == nil && ok {
@@ -607,6 +886,12 @@ func resolveConfig(configPath, baseURL, apiKey, model, repoRoot, sessionDir stri
 	if fc.RecentTurns == 0 {
 		fc.RecentTurns = 2
 	}
+	if fc.ProtectedPaths == nil {
+		fc.ProtectedPaths = []string{".git/", ".rlmkit/"}
+		if rel, err := filepath.Rel(fc.RepoRoot, fc.SessionDir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			fc.ProtectedPaths = append(fc.ProtectedPaths, filepath.ToSlash(rel)+"/")
+		}
+	}
 	if fc.ToolTimeoutSec == 0 {
 		fc.ToolTimeoutSec = 60
 	}
@@ -636,6 +921,11 @@ func buildEngine(cfg FileConfig, sessionID string) (*agent.Engine, *session.Stor
 }
 
 func buildEngineWithPrompt(cfg FileConfig, sessionID string, mode string) (*agent.Engine, *session.Store, error) {
+	switch cfg.ToolProtocol {
+	case "", "native", "text":
+	default:
+		return nil, nil, fmt.Errorf("tool_protocol must be \"native\" or \"text\", got %q", cfg.ToolProtocol)
+	}
 	tools, store, err := buildTools(cfg, sessionID)
 	if err != nil {
 		return nil, nil, err
@@ -645,8 +935,17 @@ func buildEngineWithPrompt(cfg FileConfig, sessionID string, mode string) (*agen
 	if mode == "coding" {
 		systemPrompt = coding.SystemPromptCoding
 	}
+	if cfg.RepoMap {
+		m, err := repomap.Load(context.Background(), cfg.RepoRoot, repomap.Options{MaxTokens: cfg.RepoMapTokens})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "repo map disabled: %v\n", err)
+		} else if m != "" {
+			systemPrompt += "\nRepository map (files and top-level symbols, most referenced first; may be incomplete):\n" + m
+		}
+	}
 
 	llm := openai.NewClient(cfg.BaseURL, cfg.APIKey, 120*time.Second)
+	llm.SetPrefilledThink(cfg.PrefilledThink)
 	model := strings.TrimSpace(cfg.Model)
 	if model == "" || model == "auto" {
 		ids, err := llm.Models(context.Background())
@@ -659,14 +958,53 @@ func buildEngineWithPrompt(cfg FileConfig, sessionID string, mode string) (*agen
 		model = ids[0]
 		fmt.Fprintf(os.Stderr, "auto-selected model: %s\n", model)
 	}
-	eng, err := agent.New(llm, tools, store, agent.Config{
+	agentCfg := agent.Config{
 		Model:              model,
 		SystemPrompt:       systemPrompt,
 		RecentTurns:        cfg.RecentTurns,
 		MaxIterations:      cfg.MaxIterations,
 		MaxToolConcurrency: cfg.MaxToolConcurrency,
 		ToolTimeout:        time.Duration(cfg.ToolTimeoutSec) * time.Second,
-	})
+		TextToolCalls:      cfg.ToolProtocol == "text",
+		ConstrainToolArgs:  cfg.ConstrainToolArgs,
+		StoreReasoning:     cfg.StoreReasoning,
+	}
+	if len(cfg.AnswerSchema) > 0 {
+		agentCfg.AnswerSchema = cfg.AnswerSchema
+	}
+	if cfg.GrammarFile != "" {
+		b, err := os.ReadFile(cfg.GrammarFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("grammar_file: %w", err)
+		}
+		agentCfg.Grammar = string(b)
+	}
+	if cfg.OutputSchema != "" {
+		b, err := os.ReadFile(cfg.OutputSchema)
+		if err != nil {
+			return nil, nil, fmt.Errorf("output schema: %w", err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return nil, nil, fmt.Errorf("output schema %s: must be a JSON object: %w", cfg.OutputSchema, err)
+		}
+		agentCfg.OutputSchema = json.RawMessage(b)
+		agentCfg.OutputRetries = cfg.OutputRetries
+	}
+	if cp := newCheckpointManager(cfg); cp != nil {
+		agentCfg.Checkpointer = cp
+		agentCfg.CheckpointFailed = func(err error) {
+			fmt.Fprintf(os.Stderr, "warning: no checkpoint for this turn, /undo will not be able to revert it: %v\n", err)
+		}
+	}
+	if cfg.AutoDiagnostics {
+		if t, ok := tools.Get("diagnostics"); ok {
+			if d, ok := t.(*builtin.DiagnosticsTool); ok {
+				agentCfg.AfterMutation = d.AfterMutation
+			}
+		}
+	}
+	eng, err := agent.New(llm, tools, store, agentCfg)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -678,7 +1016,8 @@ func buildTools(cfg FileConfig, sessionID string) (*core.Registry, *session.Stor
 	store := session.NewStore(cfg.SessionDir)
 	tools := core.NewRegistry()
 
-	// Use /dev/tty for ask_user to avoid fighting the main stdin scanner.
+	// Outside chat and code, use /dev/tty for ask_user to avoid fighting
+	// stdin (which may hold the prompt).
 	tty, _ := os.OpenFile("/dev/tty", os.O_RDWR, 0)
 	var reader *bufio.Reader
 	if tty != nil {
@@ -686,6 +1025,9 @@ func buildTools(cfg FileConfig, sessionID string) (*core.Registry, *session.Stor
 	}
 	p := builtin.BasicPrompter{
 		In: func() (string, error) {
+			if chatConsole != nil {
+				return chatConsole.ask()
+			}
 			if reader == nil {
 				return "", fmt.Errorf("no tty available for ask_user")
 			}
@@ -698,28 +1040,347 @@ func buildTools(cfg FileConfig, sessionID string) (*core.Registry, *session.Stor
 		Out: func(s string) { fmt.Fprint(os.Stderr, s) },
 	}
 
-	builtin.RegisterAll(tools, builtin.BuiltinConfig{
+	bcfg := builtin.BuiltinConfig{
 		RepoRoot:             cfg.RepoRoot,
 		SessionStore:         store,
 		SessionID:            sessionID,
+		ProtectedPaths:       cfg.ProtectedPaths,
+		EnableGitCommit:      cfg.EnableGitCommit,
+		GitCommitHooks:       cfg.GitCommitHooks,
+		EnableRunTests:       cfg.EnableRunTests,
 		EnableRunCommand:     cfg.EnableRunCommand,
 		AllowedCommandPrefix: cfg.AllowCommandPrefix,
 		EnableBash:           cfg.EnableBash,
 		AllowedBashPrefix:    cfg.AllowBashPrefix,
-		EnableHTTPGet:        cfg.EnableHTTPGet,
-		AllowedURLPrefix:     cfg.AllowURLPrefix,
-		EnableDuckDB:         cfg.EnableDuckDB,
-		EnableWebSearch:      cfg.EnableWebSearch,
-		WebSearchProvider:    cfg.WebSearchProvider,
-		BraveAPIToken:        cfg.BraveAPIKey,
-		AllowSearchDomain:    cfg.AllowSearchDomain,
-		WebSearchMaxResults:  cfg.WebSearchMaxResult,
-		UserPrompter:         p,
+		ShellPolicy: builtin.ShellPolicy{
+			AllowRedirects: cfg.AllowShellRedirect,
+			AllowSubshells: cfg.AllowShellSubshell,
+		},
+		Sandbox:             cfg.Sandbox,
+		EnableHTTPGet:       cfg.EnableHTTPGet,
+		AllowedURLPrefix:    cfg.AllowURLPrefix,
+		EnableDuckDB:        cfg.EnableDuckDB,
+		EnableWebSearch:     cfg.EnableWebSearch,
+		WebSearchProvider:   cfg.WebSearchProvider,
+		BraveAPIToken:       cfg.BraveAPIKey,
+		AllowSearchDomain:   cfg.AllowSearchDomain,
+		WebSearchMaxResults: cfg.WebSearchMaxResult,
+		UserPrompter:        p,
+		Plugins:             cfg.Plugins,
+		EnablePluginDir:     cfg.EnablePluginDir,
+		Vision:              cfg.Vision,
+	}
+	builtin.RegisterAll(tools, bcfg)
+	builtin.RegisterPlugins(context.Background(), tools, bcfg, func(command string, err error) {
+		fmt.Fprintf(os.Stderr, "plugin %s disabled: %v\n", command, err)
 	})
+	if len(cfg.MCPServers) > 0 {
+		mcp.Register(context.Background(), tools, cfg.MCPServers, func(name string, err error) {
+			fmt.Fprintf(os.Stderr, "mcp server %q disabled: %v\n", name, err)
+		})
+	}
 
 	return tools, store, nil
 }
 
+func runCheckpoints(args []string) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "restore") {
+		fmt.Fprintln(os.Stderr, "usage: rlmkit checkpoints list [--session-id <id>] | restore <id>")
+		os.Exit(2)
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("checkpoints", flag.ExitOnError)
+	var (
+		configPath = fs.String("config", "", "config file path (default ./rlmkit.json if present)")
+		repoRoot   = fs.String("repo-root", "", "repo root")
+		sessionDir = fs.String("session-dir", "", "session dir")
+		sessionID  = fs.String("session-id", "", "only list checkpoints for this session")
+	)
+	// Flags may come before or after the id (flag.Parse stops at the first
+	// non-flag argument).
+	var operands []string
+	rest := args[1:]
+	for {
+		_ = fs.Parse(rest)
+		if fs.NArg() == 0 {
+			break
+		}
+		operands = append(operands, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if (action == "list" && len(operands) != 0) || (action == "restore" && len(operands) != 1) {
+		fmt.Fprintln(os.Stderr, "usage: rlmkit checkpoints list [--session-id <id>] | restore <id>")
+		os.Exit(2)
+	}
+
+	cfg := resolveConfig(*configPath, "", "", "", *repoRoot, *sessionDir, 0, false, nil)
+	cfg.DisableCheckpoints = false
+	cp := newCheckpointManager(cfg)
+	if cp == nil {
+		fmt.Fprintln(os.Stderr, "error: checkpoints are unavailable (is git installed?)")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "list":
+		cps, err := cp.List(ctx, *sessionID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		for _, c := range cps {
+			fmt.Printf("%s  %s  %s  %s\n", c.ID, c.CreatedAt.Format(time.RFC3339), c.SessionID, c.Label)
+		}
+	case "restore":
+		id := operands[0]
+		backup, err := cp.Restore(ctx, id)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("restored %s (previous state saved as %s)\n", id, backup)
+	}
+}
+
+// newCheckpointManager returns nil when checkpoints are disabled or unavailable.
+func newCheckpointManager(cfg FileConfig) *checkpoint.Manager {
+	if cfg.DisableCheckpoints {
+		return nil
+	}
+	var exclude []string
+	if rel, err := filepath.Rel(cfg.RepoRoot, cfg.SessionDir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		exclude = append(exclude, rel)
+	}
+	cp, err := checkpoint.NewManager(cfg.RepoRoot, exclude)
+	if err != nil {
+		return nil
+	}
+	return cp
+}
+
+// chatConsole is set by chat and code; ask_user then reads its answers
+// through it instead of /dev/tty.
+var chatConsole *console
+
+// console owns stdin and Ctrl-C in chat and code. Ctrl-C cancels the
+// running turn (and the tools it runs) and exits at the prompt. Lines typed
+// during a turn are steering messages when steering is on, answers while
+// ask_user waits, and the next prompt's input otherwise.
+type console struct {
+	lines chan string
+
+	mu     sync.Mutex
+	ctx    context.Context // the running turn's, or nil
+	cancel context.CancelFunc
+	steer  func(string)
+	asking bool
+}
+
+func newConsole() *console {
+	c := &console{lines: make(chan string)}
+	go func() {
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			line := sc.Text()
+			c.mu.Lock()
+			steer := c.steer
+			if c.asking {
+				steer = nil
+			}
+			c.mu.Unlock()
+			if steer != nil {
+				if s := strings.TrimSpace(line); s != "" {
+					steer(s)
+				}
+				continue
+			}
+			c.lines <- line
+		}
+		close(c.lines)
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		for range sigs {
+			c.mu.Lock()
+			cancel := c.cancel
+			c.mu.Unlock()
+			if cancel == nil {
+				fmt.Println()
+				os.Exit(130)
+			}
+			cancel()
+		}
+	}()
+	return c
+}
+
+// read returns the next prompt line; false means stdin is closed.
+func (c *console) read() (string, bool) {
+	line, ok := <-c.lines
+	return line, ok
+}
+
+// beginTurn returns the turn's context. steer, if not nil, receives the
+// lines typed until endTurn.
+func (c *console) beginTurn(steer func(string)) context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.steer = steer
+	return c.ctx
+}
+
+func (c *console) endTurn() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancel()
+	c.ctx, c.cancel, c.steer = nil, nil, nil
+}
+
+// ask reads an ask_user answer; it gives up when the turn is interrupted.
+func (c *console) ask() (string, error) {
+	c.mu.Lock()
+	c.asking = true
+	ctx := c.ctx
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.asking = false
+		c.mu.Unlock()
+	}()
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	select {
+	case line, ok := <-c.lines:
+		if !ok {
+			return "", io.EOF
+		}
+		return line, nil
+	case <-done:
+		return "", ctx.Err()
+	}
+}
+
+// steerer returns the steering function for a turn: nil unless steering is
+// on.
+func steerer(cfg FileConfig, eng *agent.Engine) func(string) {
+	if !cfg.Steer {
+		return nil
+	}
+	return func(text string) {
+		eng.Steer(text)
+		fmt.Fprintln(os.Stderr, "[steering queued]")
+	}
+}
+
+// printTurnError reports a failed turn; an interrupted one is not an error.
+func printTurnError(err error) {
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "[interrupted]")
+		return
+	}
+	fmt.Fprintln(os.Stderr, "error:", err)
+}
+
+// reasoningOutput prints streamed reasoning to stderr when enabled, and ends
+// it with a newline once the answer or a tool call starts.
+type reasoningOutput struct {
+	show bool
+	open bool
+}
+
+func (r *reasoningOutput) delta(text string) {
+	if !r.show {
+		return
+	}
+	if !r.open {
+		fmt.Fprint(os.Stderr, "[thinking] ")
+		r.open = true
+	}
+	fmt.Fprint(os.Stderr, text)
+}
+
+func (r *reasoningOutput) end() {
+	if r.open {
+		fmt.Fprintln(os.Stderr)
+		r.open = false
+	}
+}
+
+// printReasoning is reasoningOutput for a non-streamed reply.
+func printReasoning(cfg FileConfig, reasoning string) {
+	if cfg.ShowReasoning && reasoning != "" {
+		fmt.Fprintln(os.Stderr, "[thinking] "+reasoning)
+	}
+}
+
+// attachImage handles /image in chat: it reads the file and adds it to the
+// images sent with the next message.
+func attachImage(images []openai.ContentPart, path string) []openai.ContentPart {
+	img, err := openai.ImageFilePart(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return images
+	}
+	images = append(images, img)
+	fmt.Printf("attached %s (%d image(s) will be sent with your next message)\n", path, len(images))
+	return images
+}
+
+// undoLastTurn restores the checkpoint of the most recent turn that has not been undone yet.
+func undoLastTurn(cfg FileConfig, store *session.Store, sessionID string) {
+	cp := newCheckpointManager(cfg)
+	if cp == nil {
+		fmt.Fprintln(os.Stderr, "error: checkpoints are disabled or unavailable")
+		return
+	}
+	ctx := context.Background()
+	turn, ok, err := store.LastUndoableTurn(ctx, sessionID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return
+	}
+	if !ok {
+		fmt.Println("nothing to undo")
+		return
+	}
+	if turn.CheckpointID == "" {
+		fmt.Fprintf(os.Stderr, "error: cannot undo turn %q: its checkpoint failed (%s); earlier checkpoints can be restored with `rlmkit checkpoints restore <id>`\n", oneLine(turn.UserInput, 60), turn.CheckpointError)
+		return
+	}
+	backup, err := cp.Restore(ctx, turn.CheckpointID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return
+	}
+	_ = store.AppendUndo(ctx, session.UndoRecord{
+		Type:         "undo",
+		SessionID:    sessionID,
+		Timestamp:    time.Now(),
+		CheckpointID: turn.CheckpointID,
+		BackupID:     backup,
+	})
+	fmt.Printf("undid turn %q (restored %s; previous state saved as %s)\n", oneLine(turn.UserInput, 60), turn.CheckpointID, backup)
+}
+
+func oneLine(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > max {
+		s = s[:max] + "..."
+	}
+	return s
+}
+
 type multiStringFlag []string
 
 func (m *multiStringFlag) String() string { return strings.Join(*m, ",") }
@@ -1,3 +1,5 @@
 module github.com/answerlayer/rlmkit
 
 go 1.22.0
+
+require mvdan.cc/sh/v3 v3.10.0
@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/answerlayer/rlmkit/internal/llm/openai"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+// Checkpointer snapshots the working tree so a turn's edits can be undone.
+type Checkpointer interface {
+	Create(ctx context.Context, sessionID string, label string) (string, error)
+}
+
+// turnCheckpoint is the checkpoint taken for a turn, or why it failed.
+type turnCheckpoint struct {
+	id  string
+	err string
+}
+
+// ensureCheckpoint takes a checkpoint before the first mutating tool call of a
+// turn. Failures are non-fatal: they are reported through
+// Config.CheckpointFailed and the turn proceeds without a checkpoint. There is
+// no second attempt, since a later snapshot would already hold the turn's
+// first edits.
+func (e *Engine) ensureCheckpoint(ctx context.Context, sessionID string, userInput string, calls []openai.ToolCall, cp *turnCheckpoint) {
+	if cp.id != "" || cp.err != "" || e.cfg.Checkpointer == nil {
+		return
+	}
+	for _, call := range calls {
+		t, ok := e.tools.Get(call.Function.Name)
+		if !ok || !core.IsMutating(t) {
+			continue
+		}
+		id, err := e.cfg.Checkpointer.Create(ctx, sessionID, userInput)
+		if err != nil {
+			cp.err = err.Error()
+			if e.cfg.CheckpointFailed != nil {
+				e.cfg.CheckpointFailed(err)
+			}
+			return
+		}
+		cp.id = id
+		return
+	}
+}
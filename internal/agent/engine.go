@@ -21,6 +21,43 @@ type Config struct {
 	MaxIterations      int
 	MaxToolConcurrency int64
 	ToolTimeout        time.Duration
+	// Checkpointer is optional; when set, the working tree is snapshotted before
+	// each turn's first mutating tool call.
+	Checkpointer Checkpointer
+	// CheckpointFailed is optional; when set, it is called when the checkpoint
+	// before a turn's first mutating tool call cannot be taken. The turn goes
+	// on without one, and TurnRecord.CheckpointError holds the reason.
+	CheckpointFailed func(err error)
+	// AfterMutation is optional; when set, it runs once after each batch of
+	// tool calls that includes a successful mutating call, and any text it
+	// returns is appended to the last such call's result (e.g. build and vet
+	// diagnostics).
+	AfterMutation func(ctx context.Context) string
+	// TextToolCalls describes the tools in the system prompt and parses calls
+	// from the assistant text instead of using the tools field, for models
+	// served without function calling support.
+	TextToolCalls bool
+	// ConstrainToolArgs asks the server, through response_format, to keep
+	// tool arguments to their schemas. It requires TextToolCalls: each reply
+	// becomes a JSON object holding either tool calls or the answer.
+	ConstrainToolArgs bool
+	// AnswerSchema is optional; when set, the final answer is constrained to
+	// this JSON schema through response_format.
+	AnswerSchema any
+	// Grammar is optional; when set, it is sent as the grammar field of every
+	// request (a GBNF grammar for llama.cpp).
+	Grammar string
+	// OutputSchema is optional; when set, the final answer must be JSON
+	// matching it. An invalid answer is sent back to the model with the
+	// problems up to OutputRetries times (default 2), and the parsed answer
+	// is returned in Result.Output. New refuses schemas with keywords the
+	// validator does not enforce (see core.CheckSchema).
+	OutputSchema  any
+	OutputRetries int
+	// StoreReasoning saves the model's reasoning (reasoning_content and
+	// <think> blocks) in TurnRecord.Reasoning. It is never part of the
+	// assistant text.
+	StoreReasoning bool
 }
 
 type Engine struct {
@@ -28,6 +65,9 @@ type Engine struct {
 	tools *core.Registry
 	store *session.Store
 	cfg   Config
+
+	mu       sync.Mutex
+	steering []string // queued by Steer, sent before the next model call
 }
 
 func New(llm *openai.Client, tools *core.Registry, store *session.Store, cfg Config) (*Engine, error) {
@@ -37,6 +77,14 @@ func New(llm *openai.Client, tools *core.Registry, store *session.Store, cfg Con
 	if cfg.Model == "" {
 		return nil, errors.New("missing model")
 	}
+	if cfg.ConstrainToolArgs && !cfg.TextToolCalls {
+		return nil, errors.New("ConstrainToolArgs requires TextToolCalls")
+	}
+	if cfg.OutputSchema != nil {
+		if err := core.CheckSchema(cfg.OutputSchema); err != nil {
+			return nil, fmt.Errorf("output schema: %w", err)
+		}
+	}
 	if cfg.MaxIterations <= 0 {
 		cfg.MaxIterations = 25
 	}
@@ -52,18 +100,43 @@ func New(llm *openai.Client, tools *core.Registry, store *session.Store, cfg Con
 	if cfg.RecentTurns < 0 {
 		cfg.RecentTurns = 0
 	}
+	if cfg.OutputRetries <= 0 {
+		cfg.OutputRetries = 2
+	}
 	return &Engine{llm: llm, tools: tools, store: store, cfg: cfg}, nil
 }
 
 type Result struct {
-	SessionID string
-	Reply     string
-	ToolCalls []session.ToolCallRecord
+	SessionID    string
+	Reply        string
+	ToolCalls    []session.ToolCallRecord
+	CheckpointID string
+	// Output is the parsed final answer when Config.OutputSchema is set.
+	Output any
+	// Reasoning is the model's reasoning over the turn, if it produced any.
+	Reasoning string
+}
+
+// Steer queues a user message for the running turn. It is sent before the
+// turn's next model call; if the turn ends first, it goes with the next
+// turn's first call.
+func (e *Engine) Steer(text string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.steering = append(e.steering, text)
+}
+
+func (e *Engine) takeSteering() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.steering
+	e.steering = nil
+	return s
 }
 
 // RunStream runs the agent turn and emits events (assistant deltas, tool start/end, final).
 // The returned error channel will receive at most one error, then close.
-func (e *Engine) RunStream(ctx context.Context, sessionID string, userInput string) (<-chan Event, <-chan error) {
+func (e *Engine) RunStream(ctx context.Context, sessionID string, userInput string, attachments ...openai.ContentPart) (<-chan Event, <-chan error) {
 	events := make(chan Event, 256)
 	errs := make(chan error, 1)
 
@@ -71,7 +144,7 @@ func (e *Engine) RunStream(ctx context.Context, sessionID string, userInput stri
 		defer close(events)
 		defer close(errs)
 
-		res, err := e.runStream(ctx, sessionID, userInput, events)
+		res, err := e.runStream(ctx, sessionID, userInput, attachments, events)
 		if err != nil {
 			errs <- err
 			return
@@ -82,15 +155,20 @@ func (e *Engine) RunStream(ctx context.Context, sessionID string, userInput stri
 	return events, errs
 }
 
-func (e *Engine) Run(ctx context.Context, sessionID string, userInput string) (Result, error) {
+// Run runs one agent turn. Attachments (such as images) are sent after the
+// user input; they are not stored in the session.
+func (e *Engine) Run(ctx context.Context, sessionID string, userInput string, attachments ...openai.ContentPart) (Result, error) {
 	if sessionID == "" {
 		return Result{}, errors.New("missing sessionID")
 	}
 	if userInput == "" {
 		return Result{}, errors.New("empty input")
 	}
+	ctx = core.WithChangeSet(ctx, &core.ChangeSet{})
 
-	messages := []openai.Message{{Role: "system", Content: e.cfg.SystemPrompt}}
+	toolDefs := e.buildToolDefs()
+	parser := e.textToolParser(toolDefs)
+	messages := []openai.Message{{Role: "system", Content: e.systemPrompt(toolDefs)}}
 
 	if e.cfg.RecentTurns > 0 {
 		turns, err := e.store.LoadRecentTurns(ctx, sessionID, e.cfg.RecentTurns)
@@ -100,31 +178,45 @@ func (e *Engine) Run(ctx context.Context, sessionID string, userInput string) (R
 				if t.UserInput != "" {
 					messages = append(messages, openai.Message{Role: "user", Content: t.UserInput})
 				}
-				if t.Assistant != "" {
-					messages = append(messages, openai.Message{Role: "assistant", Content: t.Assistant})
+				if a := historyAssistant(t); a != "" {
+					messages = append(messages, openai.Message{Role: "assistant", Content: a})
 				}
 			}
 		}
 	}
 
-	messages = append(messages, openai.Message{Role: "user", Content: userInput})
+	messages = append(messages, openai.Message{Role: "user", Content: openai.UserContent(userInput, attachments)})
 
-	toolDefs := e.buildToolDefs()
 	var toolRecords []session.ToolCallRecord
+	var checkpoint turnCheckpoint
+	var outputRetries int
+	var reasoning strings.Builder
+	var steering []string
 
 	for i := 0; i < e.cfg.MaxIterations; i++ {
-		req := openai.ChatCompletionRequest{
-			Model:      e.cfg.Model,
-			Messages:   messages,
-			Tools:      toolDefs,
-			ToolChoice: "auto",
-			Stream:     false,
+		for _, text := range e.takeSteering() {
+			messages = append(messages, openai.Message{Role: "user", Content: text})
+			steering = append(steering, text)
 		}
-
-		msg, finish, err := e.llm.ChatCompletions(ctx, req)
+		msg, finish, err := e.llm.ChatCompletions(ctx, e.chatRequest(messages, toolDefs, false))
 		if err != nil {
+			if ctx.Err() != nil {
+				return e.interrupted(ctx, session.TurnRecord{
+					SessionID:       sessionID,
+					UserInput:       userInput,
+					ToolCalls:       toolRecords,
+					CheckpointID:    checkpoint.id,
+					CheckpointError: checkpoint.err,
+					Steering:        steering,
+				}, reasoning.String())
+			}
 			return Result{}, err
 		}
+		addReasoning(&reasoning, msg.ReasoningContent)
+		raw := openai.ExtractTextContent(msg)
+		if parser != nil {
+			msg.Content, msg.ToolCalls = parser.Parse(raw, len(toolRecords))
+		}
 
 		if len(msg.ToolCalls) == 0 {
 			reply := openai.ExtractTextContent(msg)
@@ -132,57 +224,87 @@ func (e *Engine) Run(ctx context.Context, sessionID string, userInput string) (R
 				// Some servers emit tool_calls with empty content; but in this branch we have none.
 				reply = "(empty response)"
 			}
+			output, outputErr := e.checkOutput(reply)
+			if outputErr != nil && outputRetries < e.cfg.OutputRetries {
+				outputRetries++
+				messages = append(messages, e.assistantMessage(msg, raw), openai.Message{Role: "user", Content: outputRetryPrompt(outputErr)})
+				continue
+			}
 
 			// Persist turn
 			rec := session.TurnRecord{
-				Type:      "turn",
-				SessionID: sessionID,
-				Timestamp: time.Now(),
-				UserInput: userInput,
-				Assistant: reply,
-				ToolCalls: toolRecords,
+				Type:            "turn",
+				SessionID:       sessionID,
+				Timestamp:       time.Now(),
+				UserInput:       userInput,
+				Assistant:       reply,
+				ToolCalls:       toolRecords,
+				CheckpointID:    checkpoint.id,
+				CheckpointError: checkpoint.err,
+				Steering:        steering,
+			}
+			if e.cfg.StoreReasoning {
+				rec.Reasoning = reasoning.String()
 			}
 			_ = e.store.AppendTurn(ctx, rec)
 
 			return Result{
-				SessionID: sessionID,
-				Reply:     reply,
-				ToolCalls: toolRecords,
-			}, nil
+				SessionID:    sessionID,
+				Reply:        reply,
+				ToolCalls:    toolRecords,
+				CheckpointID: checkpoint.id,
+				Output:       output,
+				Reasoning:    reasoning.String(),
+			}, outputErr
 		}
 
 		// Append assistant tool call message.
-		messages = append(messages, openai.Message{
-			Role:      "assistant",
-			Content:   openai.ExtractTextContent(msg),
-			ToolCalls: msg.ToolCalls,
-		})
+		messages = append(messages, e.assistantMessage(msg, raw))
+
+		e.ensureCheckpoint(ctx, sessionID, userInput, msg.ToolCalls, &checkpoint)
 
 		// Execute tool calls (bounded concurrency, deterministic ordering).
 		toolResults, records, err := e.execToolCalls(ctx, msg.ToolCalls)
+		toolRecords = append(toolRecords, records...)
 		if err != nil {
-			return Result{}, err
+			return e.interrupted(ctx, session.TurnRecord{
+				SessionID:       sessionID,
+				UserInput:       userInput,
+				ToolCalls:       toolRecords,
+				CheckpointID:    checkpoint.id,
+				CheckpointError: checkpoint.err,
+				Steering:        steering,
+			}, reasoning.String())
 		}
-		toolRecords = append(toolRecords, records...)
 
 		// Append tool results back to model.
-		for _, tr := range toolResults {
-			messages = append(messages, tr)
-		}
+		messages = append(messages, e.toolResultMessages(toolResults)...)
 	}
 
-	return Result{}, fmt.Errorf("max iterations reached (%d)", e.cfg.MaxIterations)
+	// Save the turn anyway so its checkpoint can be undone.
+	res := e.partialTurn(ctx, session.TurnRecord{
+		SessionID:       sessionID,
+		UserInput:       userInput,
+		ToolCalls:       toolRecords,
+		CheckpointID:    checkpoint.id,
+		CheckpointError: checkpoint.err,
+		Steering:        steering,
+	}, reasoning.String())
+	return res, fmt.Errorf("max iterations reached (%d)", e.cfg.MaxIterations)
 }
 
-func (e *Engine) runStream(ctx context.Context, sessionID string, userInput string, events chan<- Event) (Result, error) {
+func (e *Engine) runStream(ctx context.Context, sessionID string, userInput string, attachments []openai.ContentPart, events chan<- Event) (Result, error) {
 	if sessionID == "" {
 		return Result{}, errors.New("missing sessionID")
 	}
 	if userInput == "" {
 		return Result{}, errors.New("empty input")
 	}
+	ctx = core.WithChangeSet(ctx, &core.ChangeSet{})
 
-	messages := []openai.Message{{Role: "system", Content: e.cfg.SystemPrompt}}
+	toolDefs := e.buildToolDefs()
+	parser := e.textToolParser(toolDefs)
+	messages := []openai.Message{{Role: "system", Content: e.systemPrompt(toolDefs)}}
 
 	if e.cfg.RecentTurns > 0 {
 		turns, err := e.store.LoadRecentTurns(ctx, sessionID, e.cfg.RecentTurns)
@@ -191,89 +313,267 @@ func (e *Engine) runStream(ctx context.Context, sessionID string, userInput stri
 				if t.UserInput != "" {
 					messages = append(messages, openai.Message{Role: "user", Content: t.UserInput})
 				}
-				if t.Assistant != "" {
-					messages = append(messages, openai.Message{Role: "assistant", Content: t.Assistant})
+				if a := historyAssistant(t); a != "" {
+					messages = append(messages, openai.Message{Role: "assistant", Content: a})
 				}
 			}
 		}
 	}
 
-	messages = append(messages, openai.Message{Role: "user", Content: userInput})
-	toolDefs := e.buildToolDefs()
+	messages = append(messages, openai.Message{Role: "user", Content: openai.UserContent(userInput, attachments)})
 	var toolRecords []session.ToolCallRecord
+	var checkpoint turnCheckpoint
+	var outputRetries int
+	var reasoning strings.Builder
+	var steering []string
 
 	var finalReply string
 	for i := 0; i < e.cfg.MaxIterations; i++ {
-		req := openai.ChatCompletionRequest{
-			Model:      e.cfg.Model,
-			Messages:   messages,
-			Tools:      toolDefs,
-			ToolChoice: "auto",
-			Stream:     true,
+		for _, text := range e.takeSteering() {
+			messages = append(messages, openai.Message{Role: "user", Content: text})
+			steering = append(steering, text)
 		}
-
-		var streamed strings.Builder
-		msg, _, err := e.llm.ChatCompletionsStream(ctx, req, func(ev openai.StreamEvent) {
+		// In text mode, tool-call markup is filtered out of displayed deltas.
+		var filter *textToolFilter
+		if parser != nil {
+			filter = &textToolFilter{parser: parser}
+		}
+		var streamed, shown, streamedReasoning strings.Builder
+		msg, _, err := e.llm.ChatCompletionsStream(ctx, e.chatRequest(messages, toolDefs, true), func(ev openai.StreamEvent) {
+			if ev.ReasoningText != "" {
+				streamedReasoning.WriteString(ev.ReasoningText)
+				events <- Event{Type: EventReasoningDelta, Text: ev.ReasoningText}
+				return
+			}
 			if ev.DeltaText == "" {
 				return
 			}
 			streamed.WriteString(ev.DeltaText)
-			events <- Event{Type: EventAssistantDelta, Text: ev.DeltaText}
+			text := ev.DeltaText
+			if filter != nil {
+				text = filter.Write(text)
+			}
+			if text != "" {
+				shown.WriteString(text)
+				events <- Event{Type: EventAssistantDelta, Text: text}
+			}
 		})
 		if err != nil {
+			if ctx.Err() != nil {
+				// Keep what the user saw of the interrupted reply.
+				addReasoning(&reasoning, streamedReasoning.String())
+				return e.interrupted(ctx, session.TurnRecord{
+					SessionID:       sessionID,
+					UserInput:       userInput,
+					Assistant:       strings.TrimSpace(shown.String()),
+					ToolCalls:       toolRecords,
+					CheckpointID:    checkpoint.id,
+					CheckpointError: checkpoint.err,
+					Steering:        steering,
+				}, reasoning.String())
+			}
 			return Result{}, err
 		}
+		if filter != nil {
+			if rest := filter.Flush(); rest != "" {
+				events <- Event{Type: EventAssistantDelta, Text: rest}
+			}
+		}
 
 		// If server didn't populate msg.Content but we streamed deltas, fill it.
 		if openai.ExtractTextContent(msg) == "" && streamed.Len() > 0 {
 			msg.Content = streamed.String()
 		}
+		addReasoning(&reasoning, msg.ReasoningContent)
+		raw := openai.ExtractTextContent(msg)
+		if parser != nil {
+			msg.Content, msg.ToolCalls = parser.Parse(raw, len(toolRecords))
+		}
 
 		if len(msg.ToolCalls) == 0 {
 			finalReply = openai.ExtractTextContent(msg)
 			if finalReply == "" {
 				finalReply = "(empty response)"
 			}
+			output, outputErr := e.checkOutput(finalReply)
+			if outputErr != nil && outputRetries < e.cfg.OutputRetries {
+				outputRetries++
+				messages = append(messages, e.assistantMessage(msg, raw), openai.Message{Role: "user", Content: outputRetryPrompt(outputErr)})
+				events <- Event{Type: EventAssistantDelta, Text: "\n"}
+				continue
+			}
 			rec := session.TurnRecord{
-				Type:      "turn",
-				SessionID: sessionID,
-				Timestamp: time.Now(),
-				UserInput: userInput,
-				Assistant: finalReply,
-				ToolCalls: toolRecords,
+				Type:            "turn",
+				SessionID:       sessionID,
+				Timestamp:       time.Now(),
+				UserInput:       userInput,
+				Assistant:       finalReply,
+				ToolCalls:       toolRecords,
+				CheckpointID:    checkpoint.id,
+				CheckpointError: checkpoint.err,
+				Steering:        steering,
+			}
+			if e.cfg.StoreReasoning {
+				rec.Reasoning = reasoning.String()
 			}
 			_ = e.store.AppendTurn(ctx, rec)
 
-			return Result{SessionID: sessionID, Reply: finalReply, ToolCalls: toolRecords}, nil
+			return Result{SessionID: sessionID, Reply: finalReply, ToolCalls: toolRecords, CheckpointID: checkpoint.id, Output: output, Reasoning: reasoning.String()}, outputErr
 		}
 
 		// Append assistant tool call message.
-		messages = append(messages, openai.Message{
-			Role:      "assistant",
-			Content:   openai.ExtractTextContent(msg),
-			ToolCalls: msg.ToolCalls,
-		})
+		messages = append(messages, e.assistantMessage(msg, raw))
+
+		e.ensureCheckpoint(ctx, sessionID, userInput, msg.ToolCalls, &checkpoint)
 
 		for _, tc := range msg.ToolCalls {
 			events <- Event{Type: EventToolStart, ToolName: tc.Function.Name}
 		}
 
 		toolResults, records, err := e.execToolCalls(ctx, msg.ToolCalls)
-		if err != nil {
-			return Result{}, err
-		}
 		toolRecords = append(toolRecords, records...)
 
 		for _, r := range records {
 			events <- Event{Type: EventToolEnd, ToolName: r.Name}
 		}
+		if err != nil {
+			return e.interrupted(ctx, session.TurnRecord{
+				SessionID:       sessionID,
+				UserInput:       userInput,
+				ToolCalls:       toolRecords,
+				CheckpointID:    checkpoint.id,
+				CheckpointError: checkpoint.err,
+				Steering:        steering,
+			}, reasoning.String())
+		}
+
+		messages = append(messages, e.toolResultMessages(toolResults)...)
+	}
+
+	// Save the turn anyway so its checkpoint can be undone.
+	res := e.partialTurn(ctx, session.TurnRecord{
+		SessionID:       sessionID,
+		UserInput:       userInput,
+		ToolCalls:       toolRecords,
+		CheckpointID:    checkpoint.id,
+		CheckpointError: checkpoint.err,
+		Steering:        steering,
+	}, reasoning.String())
+	return res, fmt.Errorf("max iterations reached (%d)", e.cfg.MaxIterations)
+}
+
+func (e *Engine) systemPrompt(toolDefs []openai.ToolDef) string {
+	if e.jsonReplies() {
+		// The answer schema is part of the JSON reply format.
+		return e.cfg.SystemPrompt + renderJSONToolPrompt(toolDefs, e.answerSchema())
+	}
+	prompt := e.cfg.SystemPrompt
+	if e.cfg.TextToolCalls {
+		prompt += renderToolPrompt(toolDefs)
+	}
+	if e.cfg.OutputSchema != nil {
+		prompt += outputPrompt(e.cfg.OutputSchema)
+	}
+	return prompt
+}
+
+// answerSchema is the schema for the answer of a JSON reply.
+func (e *Engine) answerSchema() any {
+	if e.cfg.AnswerSchema != nil {
+		return e.cfg.AnswerSchema
+	}
+	return e.cfg.OutputSchema
+}
+
+// jsonReplies reports whether text-protocol replies are constrained to a
+// JSON object (see jsonReplySchema).
+func (e *Engine) jsonReplies() bool {
+	return e.cfg.TextToolCalls && (e.cfg.ConstrainToolArgs || e.cfg.AnswerSchema != nil)
+}
 
-		for _, tr := range toolResults {
-			messages = append(messages, tr)
+// textToolParser returns nil unless tool calls use the text protocol.
+func (e *Engine) textToolParser(toolDefs []openai.ToolDef) *textToolParser {
+	if !e.cfg.TextToolCalls {
+		return nil
+	}
+	p := newTextToolParser(toolDefs)
+	p.jsonReplies = e.jsonReplies()
+	return p
+}
+
+func (e *Engine) chatRequest(messages []openai.Message, toolDefs []openai.ToolDef, stream bool) openai.ChatCompletionRequest {
+	req := openai.ChatCompletionRequest{
+		Model:    e.cfg.Model,
+		Messages: messages,
+		Stream:   stream,
+		Grammar:  e.cfg.Grammar,
+	}
+	switch {
+	case e.jsonReplies():
+		// A JSON reply can quote the stop text; the schema ends it instead.
+	case e.cfg.TextToolCalls:
+		req.Stop = []string{textToolStop}
+	default:
+		req.Tools = toolDefs
+		req.ToolChoice = "auto"
+	}
+
+	var schema any
+	switch {
+	case e.jsonReplies():
+		schema = jsonReplySchema(toolDefs, e.cfg.ConstrainToolArgs, e.answerSchema())
+	case e.cfg.AnswerSchema != nil:
+		// With native tools the server applies the format to the answer
+		// and leaves tool calls alone.
+		schema = e.cfg.AnswerSchema
+	}
+	if schema != nil {
+		req.ResponseFormat = &openai.ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &openai.JSONSchema{Name: "reply", Schema: schema},
 		}
 	}
+	return req
+}
 
-	return Result{}, fmt.Errorf("max iterations reached (%d)", e.cfg.MaxIterations)
+// assistantMessage is the history entry for a reply that called tools. In
+// text mode the model sees its own markup (raw) rather than tool_calls.
+func (e *Engine) assistantMessage(msg openai.Message, raw string) openai.Message {
+	if e.cfg.TextToolCalls {
+		return openai.Message{Role: "assistant", Content: raw}
+	}
+	return openai.Message{
+		Role:      "assistant",
+		Content:   openai.ExtractTextContent(msg),
+		ToolCalls: msg.ToolCalls,
+	}
+}
+
+// toolResultMessages are the history entries for tool results: tool
+// messages, or in text mode one user message of <tool_response> blocks.
+// Tool messages carry text only, so images from the results follow in a
+// user message.
+func (e *Engine) toolResultMessages(results []openai.Message) []openai.Message {
+	var images []openai.ContentPart
+	for i, r := range results {
+		parts, ok := r.Content.([]openai.ContentPart)
+		if !ok {
+			continue
+		}
+		results[i].Content = openai.ExtractTextContent(r)
+		for _, p := range parts {
+			if p.Type == "image_url" {
+				images = append(images, p)
+			}
+		}
+	}
+	if e.cfg.TextToolCalls {
+		return []openai.Message{{Role: "user", Content: openai.UserContent(renderToolResults(results), images)}}
+	}
+	if len(images) > 0 {
+		results = append(results, openai.Message{Role: "user", Content: openai.UserContent("Images from the tool results above:", images)})
+	}
+	return results
 }
 
 func (e *Engine) buildToolDefs() []openai.ToolDef {
@@ -300,8 +600,9 @@ func (e *Engine) execToolCalls(ctx context.Context, calls []openai.ToolCall) ([]
 	sem := make(chan struct{}, maxConc)
 
 	type item struct {
-		msg    openai.Message
-		record session.ToolCallRecord
+		msg     openai.Message
+		record  session.ToolCallRecord
+		mutated bool // a mutating tool that succeeded
 	}
 	out := make([]item, len(calls))
 
@@ -342,8 +643,27 @@ func (e *Engine) execToolCalls(ctx context.Context, calls []openai.ToolCall) ([]
 				return
 			}
 
-			in := json.RawMessage(call.Function.Arguments)
-			rec.Input = in
+			in, repaired, err := core.PrepareArgs(tool, call.Function.Arguments)
+			if in != nil {
+				rec.Input = in
+			} else {
+				// Keep the session file valid JSON: store what the model sent as a string.
+				rec.Input, _ = json.Marshal(call.Function.Arguments)
+			}
+			if err != nil {
+				rec.Error = err.Error()
+				rec.DurationMs = time.Since(start).Milliseconds()
+				out[i] = item{
+					msg: openai.Message{
+						Role:       "tool",
+						ToolCallID: call.ID,
+						Name:       call.Function.Name,
+						Content:    "Error: " + err.Error(),
+					},
+					record: rec,
+				}
+				return
+			}
 
 			toolCtx, cancel := context.WithTimeout(ctx, e.cfg.ToolTimeout)
 			defer cancel()
@@ -361,23 +681,64 @@ func (e *Engine) execToolCalls(ctx context.Context, calls []openai.ToolCall) ([]
 			if err != nil {
 				content = "Error: " + err.Error()
 			}
+			if repaired {
+				content += "\n\n(Note: the arguments were not valid JSON and were repaired to " + truncateToolOutput(string(in), 300) + ".)"
+			}
 			content = truncateToolOutput(content, 50000)
 
+			// Images ride along as parts; toolResultMessages moves them out
+			// of the tool message.
+			var msgContent any = content
+			if err == nil && len(res.Images) > 0 {
+				parts := []openai.ContentPart{openai.TextPart(content)}
+				for _, img := range res.Images {
+					parts = append(parts, openai.ImagePart(openai.ImageDataURL(img.MediaType, img.Data)))
+				}
+				msgContent = parts
+			}
+
 			out[i] = item{
 				msg: openai.Message{
 					Role:       "tool",
 					ToolCallID: call.ID,
 					Name:       call.Function.Name,
-					Content:    content,
+					Content:    msgContent,
 				},
-				record: rec,
+				record:  rec,
+				mutated: err == nil && core.IsMutating(tool),
 			}
 		}()
 	}
 
 	wg.Wait()
+	// AfterMutation (e.g. a full build) runs once per batch rather than per
+	// call; its report goes on the last successful mutating call.
+	if e.cfg.AfterMutation != nil && ctx.Err() == nil {
+		for i := len(out) - 1; i >= 0; i-- {
+			if !out[i].mutated {
+				continue
+			}
+			afterCtx, cancel := context.WithTimeout(ctx, e.cfg.ToolTimeout)
+			extra := e.cfg.AfterMutation(afterCtx)
+			cancel()
+			if extra != "" {
+				if content, ok := out[i].msg.Content.(string); ok {
+					out[i].msg.Content = content + "\n\n" + extra
+				}
+				out[i].record.Output += "\n\n" + extra
+			}
+			break
+		}
+	}
 	if err := ctx.Err(); err != nil {
-		return nil, nil, err
+		// Return the records of the calls that ran; the others never started.
+		var recs []session.ToolCallRecord
+		for _, it := range out {
+			if it.record.Name != "" {
+				recs = append(recs, it.record)
+			}
+		}
+		return nil, recs, err
 	}
 
 	msgs := make([]openai.Message, 0, len(out))
@@ -389,6 +750,51 @@ func (e *Engine) execToolCalls(ctx context.Context, calls []openai.ToolCall) ([]
 	return msgs, recs, nil
 }
 
+// interrupted saves the partial turn rec after ctx ended mid-turn (Ctrl-C in
+// the chat REPL) and returns it with ctx's error.
+func (e *Engine) interrupted(ctx context.Context, rec session.TurnRecord, reasoning string) (Result, error) {
+	rec.Interrupted = true
+	return e.partialTurn(ctx, rec, reasoning), ctx.Err()
+}
+
+// partialTurn saves a turn that ended without a final answer and returns it
+// as a Result.
+func (e *Engine) partialTurn(ctx context.Context, rec session.TurnRecord, reasoning string) Result {
+	rec.Type = "turn"
+	rec.Timestamp = time.Now()
+	if e.cfg.StoreReasoning {
+		rec.Reasoning = reasoning
+	}
+	_ = e.store.AppendTurn(context.WithoutCancel(ctx), rec)
+	return Result{
+		SessionID:    rec.SessionID,
+		Reply:        rec.Assistant,
+		ToolCalls:    rec.ToolCalls,
+		CheckpointID: rec.CheckpointID,
+		Reasoning:    reasoning,
+	}
+}
+
+// historyAssistant is the assistant text of a past turn as sent to the model.
+func historyAssistant(t session.TurnRecord) string {
+	if !t.Interrupted {
+		return t.Assistant
+	}
+	return strings.TrimSpace(t.Assistant + "\n\n(interrupted by the user)")
+}
+
+// addReasoning appends one model call's reasoning to the turn's.
+func addReasoning(sb *strings.Builder, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(text)
+}
+
 func truncateToolOutput(s string, max int) string {
 	if max <= 0 || len(s) <= max {
 		return s
@@ -4,6 +4,7 @@ type EventType string
 
 const (
 	EventAssistantDelta EventType = "assistant_delta"
+	EventReasoningDelta EventType = "reasoning_delta" // reasoning_content or <think> text
 	EventToolStart      EventType = "tool_start"
 	EventToolEnd        EventType = "tool_end"
 	EventFinal          EventType = "final"
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+// ErrOutputSchema is returned (wrapped) when the final answer does not match
+// Config.OutputSchema, after the retries are used up.
+var ErrOutputSchema = errors.New("final answer does not match the output schema")
+
+// outputPrompt asks for a final answer matching schema.
+func outputPrompt(schema any) string {
+	b, _ := json.Marshal(schema)
+	return "\n# Final answer format\n\nWhen you need no more tools, your final answer must be only a JSON value matching this schema, with no other text:\n" + string(b) + "\n"
+}
+
+// outputRetryPrompt is the user message sent after an invalid final answer.
+func outputRetryPrompt(err error) string {
+	return fmt.Sprintf("%v\nReply again with only the corrected JSON value.", err)
+}
+
+// checkOutput parses and validates a final answer against the output
+// schema. It returns nil, nil when no schema is configured.
+func (e *Engine) checkOutput(reply string) (any, error) {
+	if e.cfg.OutputSchema == nil {
+		return nil, nil
+	}
+	in := outputJSON(reply)
+	if err := core.ValidateValue(e.cfg.OutputSchema, json.RawMessage(in), "answer"); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOutputSchema, err)
+	}
+	var v any
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOutputSchema, err)
+	}
+	return v, nil
+}
+
+// outputJSON finds the JSON in a final answer: the whole reply, the inside
+// of a ```json fence, or a single object or array surrounded by prose.
+func outputJSON(reply string) string {
+	s := strings.TrimSpace(reply)
+	if strings.HasPrefix(s, "```") {
+		if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+			s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s[nl+1:]), "```"))
+		}
+	}
+	if json.Valid([]byte(s)) {
+		return s
+	}
+	if i := strings.IndexAny(s, "{["); i >= 0 {
+		if j := strings.LastIndexAny(s, "}]"); j > i && json.Valid([]byte(s[i:j+1])) {
+			return s[i : j+1]
+		}
+	}
+	return s
+}
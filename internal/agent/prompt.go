@@ -12,5 +12,6 @@ RLM pattern:
 Rules:
 - Be concise.
 - Prefer tools to guesswork.
-- When editing code, use apply_patch with a unified diff.
+- read_file prefixes lines with line numbers; never copy those prefixes into edits.
+- When editing code, use edit_file for targeted replacements or apply_patch with a unified diff; use write_file only for new files or full rewrites.
 `
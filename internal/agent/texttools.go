@@ -0,0 +1,439 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/llm/openai"
+)
+
+// Text-protocol tool calling is for models whose server does not support the
+// tools field. Tool definitions go into the system prompt, and calls are
+// parsed from the assistant text. The model is asked for Hermes/Qwen-style
+// <tool_call> blocks; Qwen3-Coder <function=...> blocks and fenced JSON
+// objects with a known tool name are understood as well.
+
+// textToolStop ends generation where a model would start inventing results.
+const textToolStop = "<tool_response>"
+
+// renderToolPrompt describes the tools and the call format for the system
+// prompt.
+func renderToolPrompt(defs []openai.ToolDef) string {
+	var sb strings.Builder
+	sb.WriteString("\n# Tools\n\n")
+	sb.WriteString("You can call the tools listed below. To call one, write a block like this:\n\n")
+	sb.WriteString("<tool_call>\n{\"name\": \"tool_name\", \"arguments\": {\"arg\": \"value\"}}\n</tool_call>\n\n")
+	sb.WriteString("Write one block per call; several blocks may follow each other. Stop after your tool calls: the results come back in the next message inside <tool_response> tags. When you need no more tools, answer normally without a <tool_call> block.\n\n")
+	writeToolList(&sb, defs)
+	return sb.String()
+}
+
+// renderJSONToolPrompt is renderToolPrompt for replies constrained to
+// jsonReplySchema.
+func renderJSONToolPrompt(defs []openai.ToolDef, answerSchema any) string {
+	var sb strings.Builder
+	sb.WriteString("\n# Tools\n\n")
+	sb.WriteString("Every reply is a single JSON object. To call tools from the list below, reply with:\n\n")
+	sb.WriteString("{\"tool_calls\": [{\"name\": \"tool_name\", \"arguments\": {\"arg\": \"value\"}}]}\n\n")
+	sb.WriteString("The results come back in the next message inside <tool_response> tags. When you need no more tools, reply with:\n\n")
+	sb.WriteString("{\"answer\": \"your answer\"}\n\n")
+	if answerSchema != nil {
+		b, _ := json.Marshal(answerSchema)
+		fmt.Fprintf(&sb, "The answer must be a JSON value matching this schema: %s\n\n", b)
+	}
+	writeToolList(&sb, defs)
+	return sb.String()
+}
+
+func writeToolList(sb *strings.Builder, defs []openai.ToolDef) {
+	sb.WriteString("<tools>\n")
+	for _, d := range defs {
+		b, _ := json.Marshal(map[string]any{
+			"name":        d.Function.Name,
+			"description": d.Function.Description,
+			"parameters":  d.Function.Parameters,
+		})
+		sb.Write(b)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString("</tools>\n")
+}
+
+// jsonReplySchema describes a reply that is either {"tool_calls": [...]} or
+// {"answer": ...}. Arguments are held to each tool's schema when
+// constrainArgs is set; the answer is a string unless answerSchema is given.
+func jsonReplySchema(defs []openai.ToolDef, constrainArgs bool, answerSchema any) map[string]any {
+	if answerSchema == nil {
+		answerSchema = map[string]any{"type": "string"}
+	}
+	answer := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"answer": answerSchema},
+		"required":   []string{"answer"},
+	}
+	if len(defs) == 0 {
+		return answer
+	}
+	var calls []any
+	for _, d := range defs {
+		var args any = map[string]any{"type": "object"}
+		if constrainArgs && d.Function.Parameters != nil {
+			args = d.Function.Parameters
+		}
+		calls = append(calls, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name":      map[string]any{"type": "string", "enum": []string{d.Function.Name}},
+				"arguments": args,
+			},
+			"required": []string{"name", "arguments"},
+		})
+	}
+	toolCalls := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"tool_calls": map[string]any{"type": "array", "minItems": 1, "items": map[string]any{"anyOf": calls}},
+		},
+		"required": []string{"tool_calls"},
+	}
+	return map[string]any{"anyOf": []any{toolCalls, answer}}
+}
+
+// renderToolResults turns tool results into the user message that answers
+// a text-protocol call.
+func renderToolResults(results []openai.Message) string {
+	var sb strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "<tool_response name=%q>\n%s\n</tool_response>", r.Name, openai.ExtractTextContent(r))
+	}
+	return sb.String()
+}
+
+// textToolParser finds tool calls in assistant text.
+type textToolParser struct {
+	params map[string]map[string]string // tool -> parameter -> JSON type
+	// jsonReplies is set when replies are constrained to jsonReplySchema.
+	jsonReplies bool
+}
+
+func newTextToolParser(defs []openai.ToolDef) *textToolParser {
+	p := &textToolParser{params: map[string]map[string]string{}}
+	for _, d := range defs {
+		types := map[string]string{}
+		var schema struct {
+			Properties map[string]struct {
+				Type any `json:"type"`
+			} `json:"properties"`
+		}
+		if b, err := json.Marshal(d.Function.Parameters); err == nil {
+			_ = json.Unmarshal(b, &schema)
+		}
+		for name, prop := range schema.Properties {
+			if t, ok := prop.Type.(string); ok {
+				types[name] = t
+			}
+		}
+		p.params[d.Function.Name] = types
+	}
+	return p
+}
+
+var (
+	toolCallRe     = regexp.MustCompile(`(?s)<tool_call>(.*?)(?:</tool_call>|\z)`)
+	functionRe     = regexp.MustCompile(`(?s)<function=([^>\s]+)>(.*?)(?:</function>|\z)`)
+	parameterRe    = regexp.MustCompile(`(?s)<parameter=([^>\s]+)>(.*?)(?:</parameter>|\z)`)
+	fencedJSONRe   = regexp.MustCompile("(?s)```(?:json|tool_call|tool)[ \t]*\n(.*?)\n?```")
+	toolResponseRe = regexp.MustCompile(`(?s)<tool_response.*`)
+	jsonNameRe     = regexp.MustCompile(`"name"\s*:\s*"([^"]+)"`)
+	jsonArgsRe     = regexp.MustCompile(`"(?:arguments|parameters)"\s*:\s*`)
+)
+
+// Parse returns the text with tool-call markup removed and the calls found,
+// in order. Calls are numbered from first, so IDs stay unique across a turn.
+func (p *textToolParser) Parse(text string, first int) (string, []openai.ToolCall) {
+	if p.jsonReplies {
+		if clean, calls, ok := p.parseJSONReply(text, first); ok {
+			return clean, calls
+		}
+		// The server did not apply the constraint; fall back to markup.
+	}
+	// Anything from an invented <tool_response> on is not the model's to say.
+	if loc := toolResponseRe.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+
+	type span struct {
+		start, end int
+		call       openai.ToolCall
+		ok         bool
+	}
+	var spans []span
+	for _, m := range toolCallRe.FindAllStringSubmatchIndex(text, -1) {
+		call, ok := p.parseBody(text[m[2]:m[3]])
+		spans = append(spans, span{m[0], m[1], call, ok})
+	}
+	for _, m := range functionRe.FindAllStringSubmatchIndex(text, -1) {
+		spans = append(spans, span{m[0], m[1], p.parseFunction(text[m[2]:m[3]], text[m[4]:m[5]]), true})
+	}
+	for _, m := range fencedJSONRe.FindAllStringSubmatchIndex(text, -1) {
+		// A fenced block is only a call if it names a known tool; otherwise
+		// it is part of the answer.
+		if call, ok := p.parseJSON(text[m[2]:m[3]], true); ok {
+			spans = append(spans, span{m[0], m[1], call, true})
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var clean strings.Builder
+	var calls []openai.ToolCall
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos {
+			continue // nested in a span already taken (e.g. <function> inside <tool_call>)
+		}
+		clean.WriteString(text[pos:s.start])
+		pos = s.end
+		if s.ok {
+			s.call.ID = fmt.Sprintf("call_%d", first+len(calls))
+			calls = append(calls, s.call)
+		}
+	}
+	clean.WriteString(text[pos:])
+	return strings.TrimSpace(clean.String()), calls
+}
+
+// parseJSONReply parses a reply constrained to jsonReplySchema. A string
+// answer is returned as is; any other answer as its JSON text.
+func (p *textToolParser) parseJSONReply(text string, first int) (string, []openai.ToolCall, bool) {
+	var v struct {
+		ToolCalls []json.RawMessage `json:"tool_calls"`
+		Answer    json.RawMessage   `json:"answer"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &v); err != nil || (v.ToolCalls == nil && v.Answer == nil) {
+		return "", nil, false
+	}
+	var calls []openai.ToolCall
+	for _, raw := range v.ToolCalls {
+		if call, ok := p.parseJSON(string(raw), false); ok {
+			call.ID = fmt.Sprintf("call_%d", first+len(calls))
+			calls = append(calls, call)
+		}
+	}
+	var answer string
+	if json.Unmarshal(v.Answer, &answer) != nil {
+		answer = strings.TrimSpace(string(v.Answer))
+	}
+	return answer, calls, true
+}
+
+// parseBody parses the inside of a <tool_call> block.
+func (p *textToolParser) parseBody(body string) (openai.ToolCall, bool) {
+	body = strings.TrimSpace(body)
+	if m := functionRe.FindStringSubmatch(body); m != nil {
+		return p.parseFunction(m[1], m[2]), true
+	}
+	body = strings.TrimPrefix(strings.TrimPrefix(body, "```json"), "```")
+	body = strings.TrimSpace(strings.TrimSuffix(body, "```"))
+	return p.parseJSON(body, false)
+}
+
+// parseJSON accepts {"name": ..., "arguments": {...}} (or "parameters"),
+// with arguments given as an object or as a JSON string. Unless known is
+// set, a call to an unknown tool is returned too, so the model is told.
+func (p *textToolParser) parseJSON(body string, known bool) (openai.ToolCall, bool) {
+	var v struct {
+		Name       string          `json:"name"`
+		Arguments  json.RawMessage `json:"arguments"`
+		Parameters json.RawMessage `json:"parameters"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &v); err != nil {
+		// Damaged JSON: if the tool name can be read, pass the arguments
+		// text on as is, so the engine's repair step (which refuses cut-off
+		// input for mutating tools) applies. Trailing text such as the
+		// enclosing brace is ignored by the repair.
+		m := jsonNameRe.FindStringSubmatch(body)
+		if m == nil || (known && p.params[m[1]] == nil) {
+			return openai.ToolCall{}, false
+		}
+		args := "{}"
+		if loc := jsonArgsRe.FindStringIndex(body); loc != nil {
+			args = body[loc[1]:]
+		}
+		return newTextCall(m[1], args), true
+	}
+	if v.Name == "" || (known && p.params[v.Name] == nil) {
+		return openai.ToolCall{}, false
+	}
+	args := v.Arguments
+	if len(args) == 0 {
+		args = v.Parameters
+	}
+	var s string
+	if json.Unmarshal(args, &s) == nil {
+		return newTextCall(v.Name, s), true
+	}
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	return newTextCall(v.Name, string(args)), true
+}
+
+// parseFunction parses Qwen3-Coder style
+// <function=name><parameter=key>value</parameter></function>. Values are
+// strings unless the tool's schema says otherwise.
+func (p *textToolParser) parseFunction(name, body string) openai.ToolCall {
+	types := p.params[name]
+	args := map[string]any{}
+	for _, m := range parameterRe.FindAllStringSubmatch(body, -1) {
+		key := m[1]
+		val := strings.TrimSuffix(strings.TrimPrefix(m[2], "\n"), "\n")
+		if t := types[key]; t != "" && t != "string" {
+			var v any
+			if json.Unmarshal([]byte(strings.TrimSpace(val)), &v) == nil {
+				args[key] = v
+				continue
+			}
+		}
+		args[key] = val
+	}
+	b, _ := json.Marshal(args)
+	return newTextCall(name, string(b))
+}
+
+func newTextCall(name, args string) openai.ToolCall {
+	return openai.ToolCall{Type: "function", Function: openai.ToolCallFunction{Name: name, Arguments: args}}
+}
+
+// textToolFilter removes tool-call markup from streamed deltas, so only the
+// prose is displayed. Text that might start markup is held back until it is
+// clear; fenced JSON is held until the fence closes and shown only if it is
+// not a tool call. JSON replies are held whole and their answer is shown on
+// Flush.
+type textToolFilter struct {
+	parser *textToolParser
+	buf    string
+	close  string // closing marker while inside markup
+	fence  string // held fence text, when close is "```"
+	done   bool   // a <tool_response> was started; drop the rest
+}
+
+var textToolOpeners = []string{"<tool_call>", "<function=", "<tool_response", "```"}
+
+// Write returns the part of delta that can be shown now.
+func (f *textToolFilter) Write(delta string) string {
+	if f.done {
+		return ""
+	}
+	f.buf += delta
+	if f.parser.jsonReplies {
+		return ""
+	}
+	var out strings.Builder
+	for f.buf != "" {
+		if f.close != "" {
+			i := strings.Index(f.buf, f.close)
+			if i < 0 {
+				// Keep enough to match a close marker split across deltas.
+				keep := len(f.close) - 1
+				if len(f.buf) > keep {
+					if f.close == "```" {
+						f.fence += f.buf[:len(f.buf)-keep]
+					}
+					f.buf = f.buf[len(f.buf)-keep:]
+				}
+				break
+			}
+			if f.close == "```" {
+				block := f.fence + f.buf[:i+3]
+				f.fence = ""
+				if _, ok := f.parser.parseJSON(fencedBody(block), true); !ok {
+					out.WriteString(block)
+				}
+			}
+			f.buf = f.buf[i+len(f.close):]
+			f.close = ""
+			continue
+		}
+
+		i := strings.IndexAny(f.buf, "<`")
+		if i < 0 {
+			out.WriteString(f.buf)
+			f.buf = ""
+			break
+		}
+		out.WriteString(f.buf[:i])
+		f.buf = f.buf[i:]
+
+		switch {
+		case strings.HasPrefix(f.buf, "<tool_response"):
+			f.done, f.buf = true, ""
+			return out.String()
+		case strings.HasPrefix(f.buf, "<tool_call>"):
+			f.buf, f.close = f.buf[len("<tool_call>"):], "</tool_call>"
+			continue
+		case strings.HasPrefix(f.buf, "<function="):
+			f.buf, f.close = f.buf[len("<function="):], "</function>"
+			continue
+		case strings.HasPrefix(f.buf, "```"):
+			nl := strings.IndexByte(f.buf, '\n')
+			if nl < 0 {
+				return out.String() // wait for the info string
+			}
+			switch strings.TrimSpace(f.buf[3:nl]) {
+			case "json", "tool_call", "tool":
+				f.fence, f.buf, f.close = f.buf[:nl+1], f.buf[nl+1:], "```"
+			default:
+				out.WriteString(f.buf[:nl+1])
+				f.buf = f.buf[nl+1:]
+			}
+			continue
+		}
+		if isMarkupPrefix(f.buf) {
+			return out.String() // wait for more text
+		}
+		out.WriteString(f.buf[:1])
+		f.buf = f.buf[1:]
+	}
+	return out.String()
+}
+
+// Flush returns held text that turned out not to be markup.
+func (f *textToolFilter) Flush() string {
+	out := ""
+	switch {
+	case f.parser.jsonReplies:
+		out, _ = f.parser.Parse(f.buf, 0)
+	case f.done:
+	case f.close == "```":
+		block := f.fence + f.buf
+		if _, ok := f.parser.parseJSON(fencedBody(block), true); !ok {
+			out = block
+		}
+	case f.close == "":
+		out = f.buf
+	}
+	f.buf, f.fence, f.close = "", "", ""
+	return out
+}
+
+func isMarkupPrefix(s string) bool {
+	for _, o := range textToolOpeners {
+		if len(s) < len(o) && strings.HasPrefix(o, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func fencedBody(block string) string {
+	if nl := strings.IndexByte(block, '\n'); nl >= 0 {
+		block = block[nl+1:]
+	}
+	return strings.TrimSuffix(strings.TrimSpace(block), "```")
+}
@@ -0,0 +1,322 @@
+// Package checkpoint snapshots the working tree so agent edits can be rolled back.
+//
+// Snapshots are stored as git commits referenced by hidden refs
+// (refs/rlmkit/checkpoints/<id>). When the repo root is the top level of a git
+// repository its own object store is used; otherwise a shadow bare repository
+// is created under <repo>/.rlmkit/checkpoints.git. The user's index, HEAD and
+// branches are never touched.
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const refPrefix = "refs/rlmkit/checkpoints/"
+
+type Checkpoint struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id,omitempty"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Manager struct {
+	repoRoot string
+	gitDir   string // shadow git dir; empty when using the repo's own .git
+	exclude  []string
+}
+
+// NewManager returns a checkpoint manager for repoRoot. exclude lists paths
+// (relative to repoRoot) that are never snapshotted or restored, such as the
+// session directory.
+func NewManager(repoRoot string, exclude []string) (*Manager, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("checkpoints require git on PATH")
+	}
+	absRoot, err := filepath.Abs(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{repoRoot: absRoot, exclude: exclude}
+
+	top, err := m.git(context.Background(), nil, "rev-parse", "--show-toplevel")
+	if err != nil || !samePath(strings.TrimSpace(top), absRoot) {
+		m.gitDir = filepath.Join(absRoot, ".rlmkit", "checkpoints.git")
+	}
+	return m, nil
+}
+
+// Create snapshots the current working tree (tracked and untracked, non-ignored
+// files) and returns the checkpoint ID.
+func (m *Manager) Create(ctx context.Context, sessionID string, label string) (string, error) {
+	if err := m.ensureShadow(ctx); err != nil {
+		return "", err
+	}
+
+	tree, err := m.writeTree(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	msg := "rlmkit checkpoint: " + oneLine(label, 80) + "\n"
+	if sessionID != "" {
+		msg += "\nsession: " + sessionID + "\n"
+	}
+	args := []string{"commit-tree", tree, "-m", msg}
+	if parent, err := m.git(ctx, nil, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		args = append(args, "-p", strings.TrimSpace(parent))
+	}
+	out, err := m.git(ctx, nil, args...)
+	if err != nil {
+		return "", err
+	}
+	commit := strings.TrimSpace(out)
+	if len(commit) < 12 {
+		return "", fmt.Errorf("unexpected commit id %q", commit)
+	}
+	id := commit[:12]
+	if _, err := m.git(ctx, nil, "update-ref", refPrefix+id, commit); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// List returns checkpoints, newest first. If sessionID is non-empty only that
+// session's checkpoints are returned.
+func (m *Manager) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	if m.gitDir != "" {
+		if _, err := os.Stat(m.gitDir); err != nil {
+			return nil, nil
+		}
+	}
+	out, err := m.git(ctx, nil, "for-each-ref",
+		"--format=%(refname:lstrip=3)%00%(creatordate:unix)%00%(contents:subject)%00%(contents:body)%1e",
+		refPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var cps []Checkpoint
+	for _, rec := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(rec, "\n"), "\x00", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		cp := Checkpoint{
+			ID:    fields[0],
+			Label: strings.TrimPrefix(fields[2], "rlmkit checkpoint: "),
+		}
+		if ts, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			cp.CreatedAt = time.Unix(ts, 0)
+		}
+		for _, line := range strings.Split(fields[3], "\n") {
+			if v, ok := strings.CutPrefix(line, "session: "); ok {
+				cp.SessionID = strings.TrimSpace(v)
+			}
+		}
+		if sessionID != "" && cp.SessionID != sessionID {
+			continue
+		}
+		cps = append(cps, cp)
+	}
+	sort.SliceStable(cps, func(i, j int) bool { return cps[i].CreatedAt.After(cps[j].CreatedAt) })
+	return cps, nil
+}
+
+// Restore rolls the working tree back to checkpoint id. The current state is
+// snapshotted first; its checkpoint ID is returned so the restore can itself be undone.
+func (m *Manager) Restore(ctx context.Context, id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.ContainsAny(id, "/ \t\n") {
+		return "", fmt.Errorf("invalid checkpoint id %q", id)
+	}
+	target, err := m.git(ctx, nil, "rev-parse", "--verify", "-q", refPrefix+id+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown checkpoint %s", id)
+	}
+	target = strings.TrimSpace(target)
+
+	backupID, err := m.Create(ctx, "", "before restore of "+id)
+	if err != nil {
+		return "", fmt.Errorf("snapshot current state: %w", err)
+	}
+
+	want, err := m.lsTree(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	have, err := m.lsTree(ctx, refPrefix+backupID)
+	if err != nil {
+		return "", err
+	}
+
+	// Remove files created after the checkpoint.
+	for p := range have {
+		if _, ok := want[p]; ok {
+			continue
+		}
+		abs := filepath.Join(m.repoRoot, filepath.FromSlash(p))
+		if err := os.Remove(abs); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return backupID, err
+		}
+		removeEmptyParents(m.repoRoot, filepath.Dir(abs))
+	}
+
+	// Write back the checkpoint's files via a throwaway index.
+	tmpDir, err := os.MkdirTemp("", "rlmkit_checkpoint_*")
+	if err != nil {
+		return backupID, err
+	}
+	defer os.RemoveAll(tmpDir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")}
+	if _, err := m.git(ctx, env, "read-tree", target); err != nil {
+		return backupID, err
+	}
+	if _, err := m.git(ctx, env, "checkout-index", "-a", "-f"); err != nil {
+		return backupID, err
+	}
+	return backupID, nil
+}
+
+func (m *Manager) writeTree(ctx context.Context) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "rlmkit_checkpoint_*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	index := filepath.Join(tmpDir, "index")
+
+	// Seed from the real index so unchanged files are not re-hashed.
+	if m.gitDir == "" {
+		if p, err := m.git(ctx, nil, "rev-parse", "--git-path", "index"); err == nil {
+			p = strings.TrimSpace(p)
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(m.repoRoot, p)
+			}
+			_ = copyFile(p, index)
+		}
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + index}
+	args := []string{"add", "-A", "--", "."}
+	for _, ex := range m.exclude {
+		args = append(args, ":(exclude)"+filepath.ToSlash(ex))
+	}
+	if _, err := m.git(ctx, env, args...); err != nil {
+		return "", err
+	}
+	out, err := m.git(ctx, env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (m *Manager) lsTree(ctx context.Context, rev string) (map[string]struct{}, error) {
+	out, err := m.git(ctx, nil, "ls-tree", "-r", "-z", "--name-only", rev)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]struct{}{}
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			files[p] = struct{}{}
+		}
+	}
+	return files, nil
+}
+
+func (m *Manager) ensureShadow(ctx context.Context) error {
+	if m.gitDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(m.gitDir, "HEAD")); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(m.gitDir, 0o755); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "git", "init", "-q", "--bare", m.gitDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git init: %s", strings.TrimSpace(string(out)))
+	}
+	if err := os.MkdirAll(filepath.Join(m.gitDir, "info"), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.gitDir, "info", "exclude"), []byte("/.rlmkit/\n/.git/\n"), 0o644)
+}
+
+func (m *Manager) git(ctx context.Context, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = m.repoRoot
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=rlmkit", "GIT_AUTHOR_EMAIL=rlmkit@localhost",
+		"GIT_COMMITTER_NAME=rlmkit", "GIT_COMMITTER_EMAIL=rlmkit@localhost",
+	)
+	if m.gitDir != "" {
+		cmd.Env = append(cmd.Env, "GIT_DIR="+m.gitDir, "GIT_WORK_TREE="+m.repoRoot)
+	}
+	cmd.Env = append(cmd.Env, env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return stdout.String(), fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+func copyFile(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, 0o600)
+}
+
+func removeEmptyParents(root, dir string) {
+	for !samePath(dir, root) && strings.HasPrefix(dir, root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func samePath(a, b string) bool {
+	ar, err := filepath.EvalSymlinks(a)
+	if err != nil {
+		ar = a
+	}
+	br, err := filepath.EvalSymlinks(b)
+	if err != nil {
+		br = b
+	}
+	return filepath.Clean(ar) == filepath.Clean(br)
+}
+
+func oneLine(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > max {
+		s = s[:max] + "..."
+	}
+	return s
+}
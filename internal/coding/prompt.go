@@ -12,9 +12,12 @@ RLM pattern:
 
 Workflow:
 1) Use list_files/search_repo/read_file to gather the minimum context.
+   In Go repos, go_outline/go_definition/go_references are faster than grepping for declarations and call sites.
+   git_status/git_diff/git_log/git_blame show what already changed and why.
 2) Propose a short plan if the task is non-trivial.
-3) Implement changes using apply_patch (unified diff).
-4) If run_command or bash is enabled and appropriate, run a small, fast check (tests/build/lint).
+3) Implement changes using edit_file (exact string replacement) for targeted edits, or apply_patch (unified diff) for larger ones.
+4) In Go repos, call diagnostics after editing to catch build, vet and formatting errors.
+   If run_tests, run_command or bash is enabled and appropriate, run a small, fast check (tests/build/lint). Prefer run_tests for Go tests.
 5) Respond with what changed and where (file paths), and any commands run.
 
 Rules:
@@ -22,5 +25,6 @@ Rules:
 - Prefer small patches; avoid unrelated refactors.
 - If you are not confident, ask a specific question or inspect more files.
 - If you need user input on a decision, call ask_user.
+- Do not commit unless the user asks; git_commit is only available when enabled.
 - If web_search is enabled and local context is insufficient, use it for targeted lookups.
 `
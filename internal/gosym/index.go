@@ -0,0 +1,479 @@
+// Package gosym indexes the Go packages of a module with go/parser and
+// go/types, for code navigation tools (definitions, references, methods,
+// outlines). It needs no external binaries.
+//
+// Only packages inside the module are type-checked. Imports from outside the
+// module (including the standard library) resolve to empty placeholder
+// packages, so identifiers from them stay unresolved; everything declared in
+// the module resolves normally.
+package gosym
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/answerlayer/rlmkit/internal/ignore"
+)
+
+// ErrNoModule is returned when the root has no go.mod.
+var ErrNoModule = errors.New("no go.mod at repo root")
+
+// Package is one indexed Go package (test files and external _test packages
+// included as separate file lists).
+type Package struct {
+	Path  string // import path
+	Name  string
+	Dir   string // slash-separated, relative to the repo root ("." for root)
+	Doc   string // package doc synopsis
+	Files []string
+
+	Types *types.Package
+
+	lib, test, xtest []*ast.File
+}
+
+// Decl is a declared object: a package-level func, type, var or const, a
+// method, or a struct field / interface method.
+type Decl struct {
+	Name string
+	Kind string // func, method, type, var, const, field, interface method
+	Recv string // receiver or enclosing type name for methods and fields
+	Pkg  *Package
+	Pos  token.Pos // defining identifier
+	Node ast.Node  // declaration node used for ranges and printing
+	Doc  string
+	Obj  types.Object
+}
+
+// Index is a type-checked snapshot of a module.
+type Index struct {
+	Root     string
+	Module   string
+	Fset     *token.FileSet
+	Packages []*Package
+
+	byPath map[string]*Package
+	decls  []*Decl
+	byPos  map[token.Pos]*Decl
+	uses   map[token.Pos][]token.Pos // defining ident pos -> use positions
+	files  map[string]*ast.File      // repo-relative path -> AST
+	stamps map[string]stamp
+
+	srcMu sync.Mutex
+	src   map[string][]string // source lines by file, for LineText
+}
+
+type stamp struct {
+	size int64
+	mod  time.Time
+}
+
+// Cache holds an Index and rebuilds it when any .go file or go.mod changes.
+type Cache struct {
+	root string
+
+	mu  sync.Mutex
+	idx *Index
+}
+
+func NewCache(root string) *Cache {
+	return &Cache{root: root}
+}
+
+// Index returns an up-to-date index, rebuilding it if files changed since the
+// last call.
+func (c *Cache) Index(ctx context.Context) (*Index, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stamps, err := scan(ctx, c.root)
+	if err != nil {
+		return nil, err
+	}
+	if c.idx != nil && sameStamps(c.idx.stamps, stamps) {
+		return c.idx, nil
+	}
+	idx, err := buildIndex(ctx, c.root, stamps)
+	if err != nil {
+		return nil, err
+	}
+	c.idx = idx
+	return idx, nil
+}
+
+func sameStamps(a, b map[string]stamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		w, ok := b[k]
+		if !ok || v.size != w.size || !v.mod.Equal(w.mod) {
+			return false
+		}
+	}
+	return true
+}
+
+// scan returns the go.mod and .go files of the module, skipping directories
+// the go tool ignores (vendor, testdata, _ and . prefixes) and nested modules.
+func scan(ctx context.Context, root string) (map[string]stamp, error) {
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+		return nil, ErrNoModule
+	}
+	stamps := map[string]stamp{}
+	err := ignore.New(root).Walk(ctx, "", func(rel string, d fs.DirEntry) error {
+		name := d.Name()
+		if d.IsDir() {
+			if name == "vendor" || name == "testdata" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+				return fs.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel), "go.mod")); err == nil {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") && rel != "go.mod" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		stamps[rel] = stamp{size: info.Size(), mod: info.ModTime()}
+		return nil
+	})
+	return stamps, err
+}
+
+func buildIndex(ctx context.Context, root string, stamps map[string]stamp) (*Index, error) {
+	idx := &Index{
+		Root:   root,
+		Fset:   token.NewFileSet(),
+		byPath: map[string]*Package{},
+		byPos:  map[token.Pos]*Decl{},
+		uses:   map[token.Pos][]token.Pos{},
+		files:  map[string]*ast.File{},
+		stamps: stamps,
+	}
+	idx.Module = ModulePath(filepath.Join(root, "go.mod"))
+	if idx.Module == "" {
+		return nil, errors.New("go.mod has no module line")
+	}
+
+	// Group files by directory.
+	byDir := map[string][]string{}
+	for rel := range stamps {
+		if strings.HasSuffix(rel, ".go") {
+			byDir[path.Dir(rel)] = append(byDir[path.Dir(rel)], rel)
+		}
+	}
+	bctx := build.Default
+	for dir, rels := range byDir {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sort.Strings(rels)
+		pkg := &Package{Dir: dir, Path: idx.Module}
+		if dir != "." {
+			pkg.Path = idx.Module + "/" + dir
+		}
+		for _, rel := range rels {
+			abs := filepath.Join(root, filepath.FromSlash(rel))
+			if ok, err := bctx.MatchFile(filepath.Dir(abs), filepath.Base(abs)); err != nil || !ok {
+				continue
+			}
+			f, _ := parser.ParseFile(idx.Fset, abs, nil, parser.ParseComments|parser.SkipObjectResolution)
+			if f == nil {
+				continue
+			}
+			idx.files[rel] = f
+			pkg.Files = append(pkg.Files, rel)
+			switch {
+			case !strings.HasSuffix(rel, "_test.go"):
+				pkg.lib = append(pkg.lib, f)
+				if pkg.Name == "" {
+					pkg.Name = f.Name.Name
+				}
+				if pkg.Doc == "" && f.Doc != nil {
+					pkg.Doc = synopsis(f.Doc.Text())
+				}
+			case strings.HasSuffix(f.Name.Name, "_test"):
+				pkg.xtest = append(pkg.xtest, f)
+			default:
+				pkg.test = append(pkg.test, f)
+			}
+		}
+		if len(pkg.Files) == 0 {
+			continue
+		}
+		if pkg.Name == "" && len(pkg.test) > 0 {
+			pkg.Name = pkg.test[0].Name.Name
+		}
+		idx.byPath[pkg.Path] = pkg
+		idx.Packages = append(idx.Packages, pkg)
+	}
+	sort.Slice(idx.Packages, func(i, j int) bool { return idx.Packages[i].Path < idx.Packages[j].Path })
+
+	imp := &importer{idx: idx, ext: map[string]*types.Package{}, busy: map[string]bool{}}
+	for _, pkg := range idx.Packages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		imp.load(pkg)
+		// In-package test files need a separate check that includes the
+		// library files; objects are matched by position, so references from
+		// tests still point at the library declarations.
+		if len(pkg.test) > 0 {
+			files := append(append([]*ast.File{}, pkg.lib...), pkg.test...)
+			info := newInfo()
+			imp.check(pkg.Path, files, info)
+			idx.record(pkg, pkg.test, info)
+		}
+		if len(pkg.xtest) > 0 {
+			info := newInfo()
+			imp.check(pkg.Path+"_test", pkg.xtest, info)
+			idx.record(pkg, pkg.xtest, info)
+		}
+	}
+	for k, v := range idx.uses {
+		idx.uses[k] = dedupPos(v)
+	}
+	return idx, nil
+}
+
+func newInfo() *types.Info {
+	return &types.Info{
+		Defs: map[*ast.Ident]types.Object{},
+		Uses: map[*ast.Ident]types.Object{},
+	}
+}
+
+// importer type-checks module packages on demand and returns empty
+// placeholders for everything else.
+type importer struct {
+	idx  *Index
+	ext  map[string]*types.Package
+	busy map[string]bool
+}
+
+func (im *importer) Import(p string) (*types.Package, error) {
+	if pkg, ok := im.idx.byPath[p]; ok && len(pkg.lib) > 0 && !im.busy[p] {
+		return im.load(pkg), nil
+	}
+	if tp, ok := im.ext[p]; ok {
+		return tp, nil
+	}
+	tp := types.NewPackage(p, guessName(p))
+	tp.MarkComplete()
+	im.ext[p] = tp
+	return tp, nil
+}
+
+func (im *importer) load(pkg *Package) *types.Package {
+	if pkg.Types != nil {
+		return pkg.Types
+	}
+	if len(pkg.lib) == 0 {
+		return nil
+	}
+	im.busy[pkg.Path] = true
+	info := newInfo()
+	pkg.Types = im.check(pkg.Path, pkg.lib, info)
+	delete(im.busy, pkg.Path)
+	im.idx.record(pkg, pkg.lib, info)
+	return pkg.Types
+}
+
+func (im *importer) check(p string, files []*ast.File, info *types.Info) *types.Package {
+	conf := types.Config{
+		Importer:    im,
+		Error:       func(error) {}, // placeholders make errors expected
+		FakeImportC: true,
+	}
+	tp, _ := conf.Check(p, im.idx.Fset, files, info)
+	return tp
+}
+
+// record adds declarations and references found in files (a subset of the
+// files described by info).
+func (idx *Index) record(pkg *Package, files []*ast.File, info *types.Info) {
+	own := map[*token.File]bool{}
+	for _, f := range files {
+		own[idx.Fset.File(f.Pos())] = true
+		idx.collectDecls(pkg, f, info)
+	}
+	for id, obj := range info.Uses {
+		if obj == nil || obj.Pkg() == nil || !own[idx.Fset.File(id.Pos())] {
+			continue
+		}
+		def := origin(obj).Pos()
+		if def.IsValid() {
+			idx.uses[def] = append(idx.uses[def], id.Pos())
+		}
+	}
+}
+
+func (idx *Index) collectDecls(pkg *Package, f *ast.File, info *types.Info) {
+	add := func(id *ast.Ident, kind, recv string, node ast.Node, doc *ast.CommentGroup) {
+		if id == nil || id.Name == "_" {
+			return
+		}
+		if _, dup := idx.byPos[id.Pos()]; dup {
+			return
+		}
+		d := &Decl{Name: id.Name, Kind: kind, Recv: recv, Pkg: pkg, Pos: id.Pos(), Node: node, Obj: info.Defs[id]}
+		if doc != nil {
+			d.Doc = doc.Text()
+		}
+		idx.byPos[id.Pos()] = d
+		idx.decls = append(idx.decls, d)
+	}
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				add(decl.Name, "method", recvTypeName(decl.Recv.List[0].Type), decl, decl.Doc)
+			} else {
+				add(decl.Name, "func", "", decl, decl.Doc)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				doc := decl.Doc
+				var node ast.Node = spec
+				if decl.Lparen == token.NoPos {
+					node = decl
+				}
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Doc != nil {
+						doc = spec.Doc
+					}
+					add(spec.Name, "type", "", node, doc)
+					idx.collectMembers(pkg, spec, info, add)
+				case *ast.ValueSpec:
+					if spec.Doc != nil {
+						doc = spec.Doc
+					}
+					for _, n := range spec.Names {
+						add(n, decl.Tok.String(), "", node, doc)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (idx *Index) collectMembers(pkg *Package, spec *ast.TypeSpec, info *types.Info, add func(*ast.Ident, string, string, ast.Node, *ast.CommentGroup)) {
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		for _, fld := range t.Fields.List {
+			for _, n := range fld.Names {
+				add(n, "field", spec.Name.Name, fld, fld.Doc)
+			}
+		}
+	case *ast.InterfaceType:
+		for _, m := range t.Methods.List {
+			for _, n := range m.Names {
+				add(n, "interface method", spec.Name.Name, m, m.Doc)
+			}
+		}
+	}
+}
+
+func recvTypeName(e ast.Expr) string {
+	for {
+		switch t := e.(type) {
+		case *ast.StarExpr:
+			e = t.X
+		case *ast.IndexExpr:
+			e = t.X
+		case *ast.IndexListExpr:
+			e = t.X
+		case *ast.ParenExpr:
+			e = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// origin maps instantiated generic objects back to their declaration.
+func origin(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
+func dedupPos(ps []token.Pos) []token.Pos {
+	sort.Slice(ps, func(i, j int) bool { return ps[i] < ps[j] })
+	out := ps[:0]
+	for i, p := range ps {
+		if i == 0 || p != ps[i-1] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ModulePath returns the module path declared in the go.mod file at gomod, or
+// "" if it cannot be read.
+func ModulePath(gomod string) string {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// guessName guesses a package name from an import path ("gopkg.in/yaml.v3"
+// -> yaml, "github.com/x/y/v2" -> y, "github.com/x/go-foo" -> foo).
+func guessName(p string) string {
+	segs := strings.Split(p, "/")
+	name := segs[len(segs)-1]
+	if len(segs) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = segs[len(segs)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+func synopsis(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if i := strings.Index(doc, "\n\n"); i >= 0 {
+		doc = doc[:i]
+	}
+	if i := strings.Index(doc, ". "); i >= 0 {
+		doc = doc[:i+1]
+	}
+	return strings.Join(strings.Fields(doc), " ")
+}
@@ -0,0 +1,317 @@
+package gosym
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Location is a repo-relative file position.
+type Location struct {
+	File string
+	Line int
+	Col  int
+}
+
+func (l Location) String() string { return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Col) }
+
+// Lookup finds declarations matching a query of the form
+//
+//	Name | Type.Member | pkg.Name | pkg.Type.Member
+//
+// where pkg is a package name or an import path (full, or relative to the
+// module, e.g. "internal/agent.Engine.Run").
+func (idx *Index) Lookup(query string) []*Decl {
+	query = strings.TrimSpace(query)
+	pathPart := ""
+	if i := strings.LastIndex(query, "/"); i >= 0 {
+		pathPart, query = query[:i+1], query[i+1:]
+	}
+	parts := strings.Split(query, ".")
+	if pathPart != "" {
+		// The first dotted part completes the package path.
+		pathPart += parts[0]
+		parts = parts[1:]
+	}
+
+	var out []*Decl
+	match := func(pkgSel string, recv, name string) {
+		for _, d := range idx.decls {
+			if d.Name != name || d.Recv != recv {
+				continue
+			}
+			if pkgSel != "" && !idx.pkgMatches(d.Pkg, pkgSel, pathPart != "") {
+				continue
+			}
+			out = append(out, d)
+		}
+	}
+	pkgSel := pathPart
+	switch {
+	case pkgSel != "" && len(parts) == 1:
+		match(pkgSel, "", parts[0])
+	case pkgSel != "" && len(parts) == 2:
+		match(pkgSel, parts[0], parts[1])
+	case len(parts) == 1:
+		match("", "", parts[0])
+		if len(out) == 0 {
+			for _, d := range idx.decls {
+				if d.Name == parts[0] && d.Recv != "" {
+					out = append(out, d)
+				}
+			}
+		}
+	case len(parts) == 2:
+		match(parts[0], "", parts[1])
+		match("", parts[0], parts[1])
+	case len(parts) == 3:
+		match(parts[0], parts[1], parts[2])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Pos < out[j].Pos })
+	return out
+}
+
+func (idx *Index) pkgMatches(p *Package, sel string, isPath bool) bool {
+	if !isPath {
+		return p.Name == sel
+	}
+	return p.Path == sel || p.Dir == sel || strings.HasSuffix(p.Path, "/"+sel)
+}
+
+// Package returns the package with the given import path, module-relative
+// directory or (if unique) name.
+func (idx *Index) Package(sel string) *Package {
+	var byName []*Package
+	for _, p := range idx.Packages {
+		if p.Path == sel || p.Dir == sel {
+			return p
+		}
+		if p.Name == sel {
+			byName = append(byName, p)
+		}
+	}
+	if len(byName) == 1 {
+		return byName[0]
+	}
+	return nil
+}
+
+// Decls returns every indexed declaration, in no particular order.
+func (idx *Index) Decls() []*Decl {
+	return idx.decls
+}
+
+// References returns the use sites of d, in file order.
+func (idx *Index) References(d *Decl) []Location {
+	ps := idx.uses[d.Pos]
+	out := make([]Location, 0, len(ps))
+	for _, p := range ps {
+		out = append(out, idx.Location(p))
+	}
+	return out
+}
+
+// Location converts a position to a repo-relative location.
+func (idx *Index) Location(p token.Pos) Location {
+	pos := idx.Fset.Position(p)
+	rel, err := filepath.Rel(idx.Root, pos.Filename)
+	if err != nil {
+		rel = pos.Filename
+	}
+	return Location{File: filepath.ToSlash(rel), Line: pos.Line, Col: pos.Column}
+}
+
+// Lines returns the first and last line of d's declaration.
+func (idx *Index) Lines(d *Decl) (int, int) {
+	return idx.Fset.Position(d.Node.Pos()).Line, idx.Fset.Position(d.Node.End()).Line
+}
+
+// QualifiedName returns e.g. "agent.Engine.Run".
+func (d *Decl) QualifiedName() string {
+	if d.Recv != "" {
+		return d.Pkg.Name + "." + d.Recv + "." + d.Name
+	}
+	return d.Pkg.Name + "." + d.Name
+}
+
+// Signature renders d's declaration without bodies, doc comments or (for
+// long type and value declarations) more than maxLines lines.
+func (idx *Index) Signature(d *Decl, maxLines int) string {
+	var node any = d.Node
+	prefix := ""
+	switch n := d.Node.(type) {
+	case *ast.FuncDecl:
+		cp := *n
+		cp.Doc, cp.Body = nil, nil
+		node = &cp
+	case *ast.GenDecl:
+		cp := *n
+		cp.Doc = nil
+		node = &cp
+	case *ast.TypeSpec:
+		prefix = "type "
+	case *ast.ValueSpec:
+		prefix = d.Kind + " "
+	case *ast.Field:
+		// Print only the type; the name is d.Name.
+		node = n.Type
+		prefix = d.Name + " "
+		if ft, ok := n.Type.(*ast.FuncType); ok && d.Kind == "interface method" {
+			node = ft
+			prefix = d.Name
+		}
+	}
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, idx.Fset, node); err != nil {
+		return d.Name
+	}
+	s := prefix + buf.String()
+	if d.Kind == "interface method" {
+		s = prefix + strings.TrimPrefix(buf.String(), "func")
+	}
+	if lines := strings.Split(s, "\n"); maxLines > 0 && len(lines) > maxLines {
+		s = strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n\t// ... %d more lines", len(lines)-maxLines)
+	}
+	return s
+}
+
+// Method is one entry of a type's method set.
+type Method struct {
+	Name     string
+	Pointer  bool   // requires a pointer receiver
+	Promoted string // embedded type it is promoted through, if any
+	Decl     *Decl  // nil if declared outside the module
+	Sig      string
+}
+
+// Methods returns the method set of the named type d (including methods
+// promoted from embedded module types), sorted by name.
+func (idx *Index) Methods(d *Decl) ([]Method, error) {
+	tn, ok := d.Obj.(*types.TypeName)
+	if !ok || d.Kind != "type" {
+		return nil, fmt.Errorf("%s is not a type", d.QualifiedName())
+	}
+	T := tn.Type()
+	valueSet := types.NewMethodSet(T)
+	inValue := map[string]bool{}
+	for i := 0; i < valueSet.Len(); i++ {
+		inValue[valueSet.At(i).Obj().Name()] = true
+	}
+	var set *types.MethodSet
+	if types.IsInterface(T) {
+		set = valueSet
+	} else {
+		set = types.NewMethodSet(types.NewPointer(T))
+	}
+
+	var out []Method
+	for i := 0; i < set.Len(); i++ {
+		sel := set.At(i)
+		obj := origin(sel.Obj())
+		m := Method{Name: obj.Name(), Pointer: !inValue[obj.Name()]}
+		if len(sel.Index()) > 1 {
+			if recv := recvNamed(obj); recv != "" {
+				m.Promoted = recv
+			}
+		}
+		if decl, ok := idx.byPos[obj.Pos()]; ok {
+			m.Decl = decl
+			m.Sig = strings.TrimSpace(idx.Signature(decl, 1))
+		} else {
+			m.Sig = obj.Name() + strings.TrimPrefix(types.TypeString(obj.Type(), nil), "func")
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func recvNamed(obj types.Object) string {
+	sig, ok := obj.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return ""
+	}
+	t := sig.Recv().Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Obj().Name()
+	}
+	return ""
+}
+
+// OutlineEntry is one top-level declaration in a file.
+type OutlineEntry struct {
+	Kind      string
+	Name      string // "T.M" for methods
+	StartLine int
+	EndLine   int
+	Summary   string // first line of the declaration
+}
+
+// Outline lists the declarations of the repo-relative Go file in order.
+func (idx *Index) Outline(rel string) (pkgName string, entries []OutlineEntry, err error) {
+	f, ok := idx.files[filepath.ToSlash(filepath.Clean(rel))]
+	if !ok {
+		if _, serr := os.Stat(filepath.Join(idx.Root, rel)); serr != nil {
+			return "", nil, serr
+		}
+		return "", nil, fmt.Errorf("%s is not an indexed Go file (wrong build constraints, or outside the module?)", rel)
+	}
+	var ds []*Decl
+	for _, d := range idx.decls {
+		if d.Kind == "field" || d.Kind == "interface method" {
+			continue
+		}
+		if idx.Fset.File(d.Pos) == idx.Fset.File(f.Pos()) {
+			ds = append(ds, d)
+		}
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].Pos < ds[j].Pos })
+	for _, d := range ds {
+		start, end := idx.Lines(d)
+		name := d.Name
+		if d.Recv != "" {
+			name = d.Recv + "." + d.Name
+		}
+		sig := idx.Signature(d, 1)
+		if i := strings.IndexByte(sig, '\n'); i >= 0 {
+			sig = sig[:i]
+		}
+		sig = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sig), "{"))
+		if len(sig) > 160 {
+			sig = sig[:160] + "..."
+		}
+		entries = append(entries, OutlineEntry{Kind: d.Kind, Name: name, StartLine: start, EndLine: end, Summary: sig})
+	}
+	return f.Name.Name, entries, nil
+}
+
+// LineText returns the trimmed source line at loc, or "".
+func (idx *Index) LineText(loc Location) string {
+	idx.srcMu.Lock()
+	defer idx.srcMu.Unlock()
+	lines, ok := idx.src[loc.File]
+	if !ok {
+		if b, err := os.ReadFile(filepath.Join(idx.Root, filepath.FromSlash(loc.File))); err == nil {
+			lines = strings.Split(string(b), "\n")
+		}
+		if idx.src == nil {
+			idx.src = map[string][]string{}
+		}
+		idx.src[loc.File] = lines
+	}
+	if loc.Line < 1 || loc.Line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[loc.Line-1])
+}
@@ -0,0 +1,196 @@
+// Package ignore implements .gitignore-style path filtering for repo walks.
+//
+// Rules are read from .gitignore and .rlmkitignore in every directory, plus
+// .git/info/exclude at the root. Deeper files and later rules take
+// precedence, and "!" re-includes a path, as in git. The .git directory is
+// always ignored.
+package ignore
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+// Files lists the per-directory ignore files that are read, in order.
+var Files = []string{".gitignore", ".rlmkitignore"}
+
+type rule struct {
+	pattern string // doublestar pattern relative to the rule's directory
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher decides whether repo-relative paths are ignored. It loads ignore
+// files lazily and is safe for concurrent use.
+type Matcher struct {
+	root string
+
+	mu    sync.Mutex
+	rules map[string][]rule // keyed by repo-relative dir ("" for root)
+}
+
+// New returns a Matcher for the directory tree at root.
+func New(root string) *Matcher {
+	return &Matcher{root: root, rules: map[string][]rule{}}
+}
+
+// Ignored reports whether rel (slash-separated, relative to root) is ignored.
+// It only consults rules from rel's ancestors; callers walking the tree are
+// expected to skip ignored directories, which also excludes their contents.
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	rel = path.Clean(rel)
+	if rel == "." || rel == "" {
+		return false
+	}
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return true
+	}
+
+	dirs := []string{""}
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' {
+			dirs = append(dirs, rel[:i])
+		}
+	}
+
+	ignored := false
+	for _, dir := range dirs {
+		sub := rel
+		if dir != "" {
+			sub = rel[len(dir)+1:]
+		}
+		for _, r := range m.dirRules(dir) {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			if util.MatchGlob(r.pattern, sub) {
+				ignored = !r.negate
+			}
+		}
+	}
+	return ignored
+}
+
+func (m *Matcher) dirRules(dir string) []rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rs, ok := m.rules[dir]; ok {
+		return rs
+	}
+	var rs []rule
+	abs := filepath.Join(m.root, filepath.FromSlash(dir))
+	if dir == "" {
+		rs = append(rs, readRules(filepath.Join(abs, ".git", "info", "exclude"))...)
+	}
+	for _, name := range Files {
+		rs = append(rs, readRules(filepath.Join(abs, name))...)
+	}
+	m.rules[dir] = rs
+	return rs
+}
+
+func readRules(p string) []rule {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var rs []rule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if r, ok := parseRule(sc.Text()); ok {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// parseRule converts one gitignore line into a rule.
+func parseRule(line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	// A slash anywhere but the end anchors the pattern to the file's directory.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	line = strings.ReplaceAll(line, "[!", "[^")
+	line = strings.ReplaceAll(line, "\\ ", " ")
+	if !anchored {
+		line = "**/" + line
+	}
+	r.pattern = line
+	return r, true
+}
+
+// WalkFunc is called for every non-ignored entry; rel is slash-separated and
+// relative to the Matcher root. Returning fs.SkipDir skips a directory.
+type WalkFunc func(rel string, d fs.DirEntry) error
+
+// Walk walks root (or the sub directory below it) in lexical order, skipping
+// ignored files and directories. Returning fs.SkipAll from fn stops the walk
+// without an error.
+func (m *Matcher) Walk(ctx context.Context, sub string, fn WalkFunc) error {
+	start := m.root
+	if sub != "" && sub != "." {
+		start = filepath.Join(m.root, filepath.FromSlash(sub))
+	}
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
+		if err != nil {
+			if p == start {
+				return err
+			}
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, rerr := filepath.Rel(m.root, p)
+		if rerr != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		// An explicitly requested sub directory is listed even if ignored.
+		if p != start && m.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(rel, d)
+	})
+	if errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
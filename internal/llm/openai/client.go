@@ -13,9 +13,10 @@ import (
 )
 
 type Client struct {
-	baseURL string
-	apiKey  string
-	http    *http.Client
+	baseURL        string
+	apiKey         string
+	http           *http.Client
+	prefilledThink bool
 }
 
 func NewClient(baseURL string, apiKey string, timeout time.Duration) *Client {
@@ -35,12 +36,23 @@ func NewClient(baseURL string, apiKey string, timeout time.Duration) *Client {
 	}
 }
 
+// SetPrefilledThink is for chat templates that open the <think> block in
+// the prompt, so the model only writes the closing tag: text before a
+// </think> with no opening tag is then reasoning. Off by default, where such
+// a tag is left in the answer.
+func (c *Client) SetPrefilledThink(on bool) {
+	c.prefilledThink = on
+}
+
 type Message struct {
 	Role       string     `json:"role"`
 	Content    any        `json:"content,omitempty"`
 	Name       string     `json:"name,omitempty"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+	// ReasoningContent is the reasoning of a reply, when the server returns
+	// it separately or the model wrote it in a <think> block.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 type ToolDef struct {
@@ -72,6 +84,22 @@ type ChatCompletionRequest struct {
 	ToolChoice any       `json:"tool_choice,omitempty"` // "auto"
 	Stream     bool      `json:"stream,omitempty"`
 	MaxTokens  int       `json:"max_tokens,omitempty"`
+	Stop       []string  `json:"stop,omitempty"`
+	// ResponseFormat constrains the reply to JSON, optionally matching a schema.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Grammar is a GBNF grammar for servers that accept one (llama.cpp).
+	Grammar string `json:"grammar,omitempty"`
+}
+
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "text", "json_object" or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+	Strict bool   `json:"strict,omitempty"`
 }
 
 type ChatCompletionResponse struct {
@@ -114,6 +142,14 @@ func (c *Client) ChatCompletions(ctx context.Context, req ChatCompletionRequest)
 	}
 
 	msg := out.Choices[0].Message
+	if text, ok := msg.Content.(string); ok {
+		answer, reasoning := SplitThink(text, c.prefilledThink)
+		msg.Content = answer
+		if msg.ReasoningContent != "" && reasoning != "" {
+			msg.ReasoningContent += "\n"
+		}
+		msg.ReasoningContent += reasoning
+	}
 	return msg, out.Choices[0].FinishReason, nil
 }
 
@@ -140,9 +176,12 @@ func ExtractTextContent(msg Message) string {
 		return v
 	case nil:
 		return ""
+	case []ContentPart:
+		return contentText(v)
+	case []any:
+		// Some servers return content as an array of parts.
+		return decodedContentText(v)
 	default:
-		// Some servers return content as array of parts. We keep MVP simple:
-		// if it isn't a string, JSON-encode for visibility.
 		b, _ := json.Marshal(v)
 		return string(b)
 	}
@@ -0,0 +1,97 @@
+package openai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContentPart is one part of a message whose content is a list of parts
+// (Message.Content set to a []ContentPart).
+type ContentPart struct {
+	Type     string    `json:"type"` // "text" or "image_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL    string `json:"url"`              // http(s) or base64 data URL
+	Detail string `json:"detail,omitempty"` // "auto", "low" or "high"
+}
+
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: "text", Text: text}
+}
+
+func ImagePart(url string) ContentPart {
+	return ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: url}}
+}
+
+// ImageDataURL encodes image data as a base64 data URL.
+func ImageDataURL(mediaType string, data []byte) string {
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// MaxImageBytes is the largest image file ImageFilePart accepts.
+const MaxImageBytes = 20 << 20
+
+// ImageFilePart reads a png, jpeg, gif or webp file into an image part.
+func ImageFilePart(path string) (ContentPart, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ContentPart{}, err
+	}
+	if info.Size() > MaxImageBytes {
+		return ContentPart{}, fmt.Errorf("%s: image is larger than %d MiB", filepath.Base(path), MaxImageBytes>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ContentPart{}, err
+	}
+	mediaType := http.DetectContentType(data)
+	switch mediaType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return ContentPart{}, fmt.Errorf("%s: not a supported image (%s)", filepath.Base(path), mediaType)
+	}
+	return ImagePart(ImageDataURL(mediaType, data)), nil
+}
+
+// UserContent is the content of a user message: the text alone, or the text
+// followed by the extra parts (such as images).
+func UserContent(text string, parts []ContentPart) any {
+	if len(parts) == 0 {
+		return text
+	}
+	return append([]ContentPart{TextPart(text)}, parts...)
+}
+
+// contentText joins the text parts of a content list.
+func contentText(parts []ContentPart) string {
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// decodedContentText is contentText for parts decoded from a response,
+// which are maps rather than ContentParts.
+func decodedContentText(parts []any) string {
+	var texts []string
+	for _, p := range parts {
+		m, ok := p.(map[string]any)
+		if !ok || m["type"] != "text" {
+			continue
+		}
+		if s, ok := m["text"].(string); ok {
+			texts = append(texts, s)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,139 @@
+package openai
+
+import "strings"
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// thinkSplitter separates inline <think>...</think> reasoning from answer
+// text in streamed content. An opening tag is only recognized before any
+// answer text. With prefilled set (chat templates that open the block in the
+// prompt), a closing tag without one turns the text before it into
+// reasoning; text already passed on as answer by then cannot be taken back,
+// but answer() is right. Otherwise such a tag is ordinary answer text.
+type thinkSplitter struct {
+	prefilled bool
+
+	inThink bool
+	started bool // answer text has begun
+	opened  bool // a <think> was seen
+	closed  bool // a </think> was seen
+	pending string
+	answerB strings.Builder
+	reasonB strings.Builder
+}
+
+// Write returns the answer and reasoning text that delta completes.
+func (s *thinkSplitter) Write(delta string) (answer, reasoning string) {
+	buf := s.pending + delta
+	s.pending = ""
+	var ans, rea strings.Builder
+	for buf != "" {
+		switch {
+		case s.inThink:
+			if i := strings.Index(buf, thinkClose); i >= 0 {
+				rea.WriteString(buf[:i])
+				buf = buf[i+len(thinkClose):]
+				s.inThink, s.closed = false, true
+				continue
+			}
+			keep := partialSuffix(buf, thinkClose)
+			rea.WriteString(buf[:len(buf)-keep])
+			s.pending = buf[len(buf)-keep:]
+			buf = ""
+
+		case !s.started:
+			// Hold leading whitespace: it belongs before a <think> or is
+			// dropped after one.
+			trimmed := strings.TrimLeft(buf, " \t\r\n")
+			if trimmed == "" {
+				if !s.closed {
+					s.pending = buf
+				}
+				buf = ""
+				continue
+			}
+			if !s.closed {
+				if strings.HasPrefix(trimmed, thinkOpen) {
+					s.inThink, s.opened = true, true
+					buf = trimmed[len(thinkOpen):]
+					continue
+				}
+				if strings.HasPrefix(thinkOpen, trimmed) {
+					s.pending = buf
+					buf = ""
+					continue
+				}
+			} else {
+				buf = trimmed
+			}
+			s.started = true
+
+		default:
+			if s.opened || s.closed || !s.prefilled {
+				ans.WriteString(buf)
+				buf = ""
+				continue
+			}
+			// Watch for a closing tag with no opening one.
+			if i := strings.Index(buf, thinkClose); i >= 0 {
+				s.reasonB.WriteString(s.answerB.String())
+				s.reasonB.WriteString(ans.String())
+				s.answerB.Reset()
+				ans.Reset()
+				rea.WriteString(buf[:i])
+				buf = buf[i+len(thinkClose):]
+				s.started, s.closed = false, true
+				continue
+			}
+			keep := partialSuffix(buf, thinkClose)
+			ans.WriteString(buf[:len(buf)-keep])
+			s.pending = buf[len(buf)-keep:]
+			buf = ""
+		}
+	}
+	s.answerB.WriteString(ans.String())
+	s.reasonB.WriteString(rea.String())
+	return ans.String(), rea.String()
+}
+
+// Flush returns held text at the end of the stream.
+func (s *thinkSplitter) Flush() (answer, reasoning string) {
+	rest := s.pending
+	s.pending = ""
+	if s.inThink {
+		s.reasonB.WriteString(rest)
+		return "", rest
+	}
+	if !s.started {
+		// Only whitespace, or an unfinished "<think" prefix: keep it as text.
+		rest = strings.TrimSpace(rest)
+	}
+	s.answerB.WriteString(rest)
+	return rest, ""
+}
+
+func (s *thinkSplitter) answer() string    { return s.answerB.String() }
+func (s *thinkSplitter) reasoning() string { return s.reasonB.String() }
+
+// partialSuffix returns the length of the longest suffix of s that is a
+// proper prefix of tag.
+func partialSuffix(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// SplitThink separates inline <think> reasoning from the answer text of a
+// complete reply; prefilled is as for Client.SetPrefilledThink.
+func SplitThink(text string, prefilled bool) (answer, reasoning string) {
+	s := thinkSplitter{prefilled: prefilled}
+	s.Write(text)
+	s.Flush()
+	return s.answer(), s.reasoning()
+}
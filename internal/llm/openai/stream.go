@@ -10,15 +10,19 @@ import (
 	"strings"
 )
 
+// StreamEvent carries answer text or reasoning text (reasoning_content
+// deltas and inline <think> blocks), never both.
 type StreamEvent struct {
-	DeltaText string
+	DeltaText     string
+	ReasoningText string
 }
 
 type streamChunk struct {
 	Choices []struct {
 		Delta struct {
-			Content   *string               `json:"content,omitempty"`
-			ToolCalls []streamToolCallDelta `json:"tool_calls,omitempty"`
+			Content          *string               `json:"content,omitempty"`
+			ReasoningContent *string               `json:"reasoning_content,omitempty"`
+			ToolCalls        []streamToolCallDelta `json:"tool_calls,omitempty"`
 		} `json:"delta"`
 		FinishReason *string `json:"finish_reason,omitempty"`
 	} `json:"choices"`
@@ -61,7 +65,8 @@ func (c *Client) ChatCompletionsStream(ctx context.Context, req ChatCompletionRe
 
 	_ = body // keep body referenced for clarity (newChatRequest may return it)
 
-	var content strings.Builder
+	think := thinkSplitter{prefilled: c.prefilledThink}
+	var reasoningContent strings.Builder
 	var finishReason string
 	var toolCalls []ToolCall
 
@@ -105,13 +110,17 @@ func (c *Client) ChatCompletionsStream(ctx context.Context, req ChatCompletionRe
 			finishReason = *ch.FinishReason
 		}
 
-		if ch.Delta.Content != nil && *ch.Delta.Content != "" {
-			d := *ch.Delta.Content
-			content.WriteString(d)
+		if ch.Delta.ReasoningContent != nil && *ch.Delta.ReasoningContent != "" {
+			d := *ch.Delta.ReasoningContent
+			reasoningContent.WriteString(d)
 			if onEvent != nil {
-				onEvent(StreamEvent{DeltaText: d})
+				onEvent(StreamEvent{ReasoningText: d})
 			}
 		}
+		if ch.Delta.Content != nil && *ch.Delta.Content != "" {
+			answer, reasoning := think.Write(*ch.Delta.Content)
+			emitContent(onEvent, answer, reasoning)
+		}
 
 		if len(ch.Delta.ToolCalls) > 0 {
 			for _, td := range ch.Delta.ToolCalls {
@@ -145,11 +154,33 @@ func (c *Client) ChatCompletionsStream(ctx context.Context, req ChatCompletionRe
 	if err := sc.Err(); err != nil {
 		return Message{}, "", err
 	}
+	answer, rest := think.Flush()
+	emitContent(onEvent, answer, rest)
 
+	if inline := think.reasoning(); inline != "" {
+		if reasoningContent.Len() > 0 {
+			reasoningContent.WriteString("\n")
+		}
+		reasoningContent.WriteString(inline)
+	}
 	msg := Message{
-		Role:      "assistant",
-		Content:   content.String(),
-		ToolCalls: toolCalls,
+		Role:             "assistant",
+		Content:          think.answer(),
+		ReasoningContent: reasoningContent.String(),
+		ToolCalls:        toolCalls,
 	}
 	return msg, finishReason, nil
 }
+
+// emitContent sends the parts of a content delta split by thinkSplitter.
+func emitContent(onEvent func(StreamEvent), answer, reasoning string) {
+	if onEvent == nil {
+		return
+	}
+	if reasoning != "" {
+		onEvent(StreamEvent{ReasoningText: reasoning})
+	}
+	if answer != "" {
+		onEvent(StreamEvent{DeltaText: answer})
+	}
+}
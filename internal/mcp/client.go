@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ServerConfig configures one MCP server ("mcp_servers" in rlmkit.json).
+// Set Command for a stdio server or URL for a streamable HTTP server. $VAR
+// references in Command, Args, Env values, URL and Headers values are
+// expanded from the environment, so secrets can stay out of the file.
+type ServerConfig struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Dir     string            `json:"dir,omitempty"`
+
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Tools, when non-empty, limits which remote tools are registered.
+	Tools []string `json:"tools,omitempty"`
+	// TimeoutSec bounds connecting and listing tools (default 30).
+	TimeoutSec int  `json:"timeout_sec,omitempty"`
+	Disabled   bool `json:"disabled,omitempty"`
+}
+
+// conn is an initialized transport.
+type conn interface {
+	call(ctx context.Context, method string, params any) (json.RawMessage, error)
+	notify(ctx context.Context, method string, params any) error
+	closed() bool
+	close() error
+}
+
+// connError marks a failure of the connection itself, as opposed to an error
+// returned by the server. The client drops the connection and reconnects on
+// the next call.
+type connError struct{ err error }
+
+func (e *connError) Error() string { return e.err.Error() }
+func (e *connError) Unwrap() error { return e.err }
+
+// Client is a connection to one MCP server. It connects lazily and
+// reconnects (with backoff) after the server crashes or the connection is
+// lost; calls that were in flight at that moment fail rather than being
+// retried, since tools may not be idempotent.
+type Client struct {
+	name string
+	cfg  ServerConfig
+
+	mu      sync.Mutex
+	conn    conn
+	lastErr error
+	retryAt time.Time
+	backoff time.Duration
+}
+
+func NewClient(name string, cfg ServerConfig) (*Client, error) {
+	if (cfg.Command == "") == (cfg.URL == "") {
+		return nil, fmt.Errorf("mcp server %q: set exactly one of command or url", name)
+	}
+	return &Client{name: name, cfg: cfg}, nil
+}
+
+func (c *Client) Name() string { return c.name }
+
+func (c *Client) get(ctx context.Context) (conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && !c.conn.closed() {
+		return c.conn, nil
+	}
+	if c.conn != nil {
+		_ = c.conn.close()
+		c.conn = nil
+	}
+	if wait := time.Until(c.retryAt); wait > 0 {
+		return nil, fmt.Errorf("mcp server %q unavailable: %v (retrying in %s)", c.name, c.lastErr, wait.Round(time.Second))
+	}
+	cn, err := c.dial(ctx)
+	if err != nil {
+		c.lastErr = err
+		if c.backoff == 0 {
+			c.backoff = time.Second
+		} else if c.backoff < 30*time.Second {
+			c.backoff *= 2
+		}
+		c.retryAt = time.Now().Add(c.backoff)
+		return nil, fmt.Errorf("mcp server %q: %w", c.name, err)
+	}
+	c.conn, c.backoff, c.retryAt = cn, 0, time.Time{}
+	return cn, nil
+}
+
+// dial starts the transport and performs the initialize handshake.
+func (c *Client) dial(ctx context.Context) (conn, error) {
+	var cn conn
+	var hc *httpConn
+	if c.cfg.Command != "" {
+		sc, err := startStdio(c.cfg)
+		if err != nil {
+			return nil, err
+		}
+		cn = sc
+	} else {
+		hc = newHTTPConn(c.cfg)
+		cn = hc
+	}
+
+	raw, err := cn.call(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: "rlmkit", Version: "dev"},
+	})
+	if err != nil {
+		_ = cn.close()
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	var res initializeResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		_ = cn.close()
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	if !supportedVersion(res.ProtocolVersion) {
+		_ = cn.close()
+		return nil, fmt.Errorf("unsupported protocol version %q", res.ProtocolVersion)
+	}
+	if hc != nil {
+		hc.setVersion(res.ProtocolVersion)
+	}
+	if err := cn.notify(ctx, "notifications/initialized", nil); err != nil {
+		_ = cn.close()
+		return nil, err
+	}
+	return cn, nil
+}
+
+// drop discards cn if it is still the current connection.
+func (c *Client) drop(cn conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == cn {
+		_ = cn.close()
+		c.conn = nil
+	}
+}
+
+// call sends a request, reconnecting once if the connection was found dead
+// before the request could have been processed.
+func (c *Client) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	for attempt := 0; ; attempt++ {
+		cn, err := c.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := cn.call(ctx, method, params)
+		var ce *connError
+		if errors.As(err, &ce) {
+			c.drop(cn)
+			if errors.Is(err, errSessionExpired) && attempt == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("mcp server %q: %w (it will be reconnected on the next call)", c.name, err)
+		}
+		return raw, err
+	}
+}
+
+// ListTools returns all tools the server offers.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var out []Tool
+	cursor := ""
+	for {
+		raw, err := c.call(ctx, "tools/list", listToolsParams{Cursor: cursor})
+		if err != nil {
+			return nil, err
+		}
+		var res listToolsResult
+		if err := json.Unmarshal(raw, &res); err != nil {
+			return nil, fmt.Errorf("tools/list: %w", err)
+		}
+		out = append(out, res.Tools...)
+		if res.NextCursor == "" || res.NextCursor == cursor {
+			return out, nil
+		}
+		cursor = res.NextCursor
+	}
+}
+
+// CallTool invokes a remote tool. A tool-level failure is returned as a
+// result with IsError set, not as an error.
+func (c *Client) CallTool(ctx context.Context, name string, args json.RawMessage) (*CallToolResult, error) {
+	raw, err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: args})
+	if err != nil {
+		return nil, err
+	}
+	var res CallToolResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, fmt.Errorf("tools/call: %w", err)
+	}
+	return &res, nil
+}
+
+// Close shuts the connection down; a stdio server is asked to exit.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.close()
+	c.conn = nil
+	return err
+}
+
+// answerServerRequest handles requests a server sends to the client. rlmkit
+// declares no client capabilities, so only ping is supported.
+func answerServerRequest(m message) message {
+	if m.Method == "ping" {
+		return newResult(m.ID, struct{}{})
+	}
+	return newError(m.ID, codeMethodNotFound, "method not found: "+m.Method)
+}
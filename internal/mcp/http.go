@@ -0,0 +1,246 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errSessionExpired is returned when the server no longer knows our session
+// (HTTP 404). The request was not processed, so it is safe to retry on a new
+// session.
+var errSessionExpired = errors.New("session expired")
+
+// httpConn implements the streamable HTTP transport: every message is POSTed
+// to one endpoint, and the reply is either a JSON body or an SSE stream that
+// ends with the response.
+type httpConn struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	nextID int64
+
+	mu        sync.Mutex
+	sessionID string
+	version   string // negotiated protocol version, sent after initialize
+}
+
+func newHTTPConn(cfg ServerConfig) *httpConn {
+	h := map[string]string{}
+	for k, v := range cfg.Headers {
+		h[k] = os.ExpandEnv(v)
+	}
+	return &httpConn{
+		url:     os.ExpandEnv(cfg.URL),
+		headers: h,
+		// Calls are bounded by their context; this only guards against a
+		// server that accepts the connection and never answers.
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+func (c *httpConn) setVersion(v string) {
+	c.mu.Lock()
+	c.version = v
+	c.mu.Unlock()
+}
+
+func (c *httpConn) post(ctx context.Context, m message) (*http.Response, error) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	c.setHeaders(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &connError{err}
+	}
+	c.mu.Lock()
+	hadSession := c.sessionID != ""
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" && !hadSession {
+		c.sessionID = id
+	}
+	c.mu.Unlock()
+
+	if resp.StatusCode == http.StatusNotFound && hadSession {
+		resp.Body.Close()
+		return nil, &connError{errSessionExpired}
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		err := fmt.Errorf("HTTP %s: %s", resp.Status, strings.TrimSpace(string(b)))
+		if resp.StatusCode >= 500 {
+			return nil, &connError{err}
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *httpConn) setHeaders(req *http.Request) {
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", c.sessionID)
+	}
+	if c.version != "" {
+		req.Header.Set("MCP-Protocol-Version", c.version)
+	}
+}
+
+func (c *httpConn) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := atomic.AddInt64(&c.nextID, 1)
+	req, err := newRequest(id, method, params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result *message
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch ct {
+	case "application/json":
+		var m message
+		if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+			return nil, &connError{fmt.Errorf("decode response: %w", err)}
+		}
+		result = &m
+	case "text/event-stream":
+		err := readSSE(resp.Body, func(data []byte) bool {
+			var m message
+			if json.Unmarshal(data, &m) != nil {
+				return false
+			}
+			switch {
+			case m.isResponse() && string(m.ID) == string(req.ID):
+				result = &m
+				return true
+			case m.isRequest():
+				c.reply(ctx, answerServerRequest(m))
+			}
+			return false
+		})
+		if err != nil && result == nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, &connError{err}
+		}
+	default:
+		return nil, &connError{fmt.Errorf("unexpected content type %q", ct)}
+	}
+	if result == nil {
+		return nil, &connError{errors.New("stream ended without a response")}
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Result, nil
+}
+
+// reply sends our response to a request the server made mid-stream.
+func (c *httpConn) reply(ctx context.Context, m message) {
+	if resp, err := c.post(ctx, m); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func (c *httpConn) notify(ctx context.Context, method string, params any) error {
+	m, err := newNotification(method, params)
+	if err != nil {
+		return err
+	}
+	resp, err := c.post(ctx, m)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *httpConn) closed() bool { return false }
+
+// close ends the session (best effort) so the server can free it.
+func (c *httpConn) close() error {
+	c.mu.Lock()
+	sid := c.sessionID
+	c.mu.Unlock()
+	if sid == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url, nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// readSSE calls fn with the data of each server-sent event until fn returns
+// true or the stream ends.
+func readSSE(r io.Reader, fn func(data []byte) bool) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	var data bytes.Buffer
+	for {
+		line, err := br.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				if fn(bytes.TrimSuffix(data.Bytes(), []byte("\n"))) {
+					return nil
+				}
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			data.WriteByte('\n')
+		}
+		// event:, id:, retry: and comment lines are not needed.
+		if err != nil {
+			if data.Len() > 0 && fn(bytes.TrimSuffix(data.Bytes(), []byte("\n"))) {
+				return nil
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
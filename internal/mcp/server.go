@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+// Server exposes the tools of a core.Registry to an MCP host.
+type Server struct {
+	tools       *core.Registry
+	info        Implementation
+	toolTimeout time.Duration
+
+	wmu sync.Mutex
+	w   io.Writer
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc // keyed by raw request ID
+}
+
+// NewServer returns a server for r. Each tool call is bounded by toolTimeout
+// (default 60s), as in the agent engine.
+func NewServer(r *core.Registry, info Implementation, toolTimeout time.Duration) *Server {
+	if toolTimeout <= 0 {
+		toolTimeout = 60 * time.Second
+	}
+	return &Server{tools: r, info: info, toolTimeout: toolTimeout, inflight: map[string]context.CancelFunc{}}
+}
+
+// ServeStdio reads newline-delimited requests from r and writes responses to
+// w until r reaches EOF (the host closed stdin) or ctx is done. Tool calls run
+// concurrently; requests in flight are cancelled when serving stops.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.w = w
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var m message
+			if jerr := json.Unmarshal(line, &m); jerr != nil {
+				s.send(newError(nil, codeParseError, "parse error: "+jerr.Error()))
+			} else if m.isRequest() && m.Method == "tools/call" {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					s.send(s.callTool(ctx, m))
+				}()
+			} else if m.isRequest() {
+				s.send(s.handle(m))
+			} else if m.isNotification() {
+				s.notification(m)
+			}
+			// Responses are not expected: the server sends no requests.
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Server) send(m message) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		b, _ = json.Marshal(newError(m.ID, codeInternalError, err.Error()))
+	}
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_, _ = s.w.Write(append(b, '\n'))
+}
+
+func (s *Server) handle(m message) message {
+	switch m.Method {
+	case "initialize":
+		var p initializeParams
+		_ = json.Unmarshal(m.Params, &p)
+		v := ProtocolVersion
+		if supportedVersion(p.ProtocolVersion) {
+			v = p.ProtocolVersion
+		}
+		return newResult(m.ID, initializeResult{
+			ProtocolVersion: v,
+			Capabilities:    map[string]any{"tools": map[string]any{"listChanged": false}},
+			ServerInfo:      s.info,
+		})
+	case "ping":
+		return newResult(m.ID, struct{}{})
+	case "tools/list":
+		all := s.tools.All()
+		tools := make([]Tool, 0, len(all))
+		for _, t := range all {
+			schema, err := json.Marshal(t.InputSchema())
+			if err != nil {
+				return newError(m.ID, codeInternalError, fmt.Sprintf("%s: %v", t.Name(), err))
+			}
+			readOnly := !core.IsMutating(t)
+			tools = append(tools, Tool{
+				Name:        t.Name(),
+				Description: t.Description(),
+				InputSchema: schema,
+				Annotations: &ToolAnnotations{ReadOnlyHint: &readOnly},
+			})
+		}
+		return newResult(m.ID, listToolsResult{Tools: tools})
+	}
+	return newError(m.ID, codeMethodNotFound, "method not found: "+m.Method)
+}
+
+func (s *Server) notification(m message) {
+	if m.Method != "notifications/cancelled" {
+		return
+	}
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(m.Params, &p) != nil {
+		return
+	}
+	s.mu.Lock()
+	cancel := s.inflight[string(p.RequestID)]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// callTool runs a tool. Tool failures are reported in the result (isError)
+// so the host's model can see them; unknown tools are protocol errors.
+func (s *Server) callTool(ctx context.Context, m message) message {
+	var p callToolParams
+	if err := json.Unmarshal(m.Params, &p); err != nil {
+		return newError(m.ID, codeInvalidParams, "invalid params: "+err.Error())
+	}
+	t, ok := s.tools.Get(p.Name)
+	if !ok {
+		return newError(m.ID, codeInvalidParams, "unknown tool: "+p.Name)
+	}
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.toolTimeout)
+	defer cancel()
+	key := string(m.ID)
+	s.mu.Lock()
+	s.inflight[key] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+	}()
+
+	res, err := t.Execute(ctx, args)
+	if err != nil {
+		return newResult(m.ID, CallToolResult{
+			Content: []Content{{Type: "text", Text: "Error: " + err.Error()}},
+			IsError: true,
+		})
+	}
+	content := []Content{{Type: "text", Text: res.Content}}
+	for _, img := range res.Images {
+		content = append(content, Content{Type: "image", Data: base64.StdEncoding.EncodeToString(img.Data), MimeType: img.MediaType})
+	}
+	return newResult(m.ID, CallToolResult{Content: content})
+}
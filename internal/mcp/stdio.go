@@ -0,0 +1,246 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// stdioConn talks to a server subprocess over newline-delimited JSON on its
+// stdin and stdout. The connection is lost when the process exits.
+type stdioConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *tailBuffer
+
+	wmu sync.Mutex // serializes writes to stdin
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcResult
+	err     error // set once the connection is lost
+	done    chan struct{}
+}
+
+type rpcResult struct {
+	msg message
+	err error
+}
+
+func startStdio(cfg ServerConfig) (*stdioConn, error) {
+	cmd := exec.Command(os.ExpandEnv(cfg.Command), expandAll(cfg.Args)...)
+	cmd.Dir = cfg.Dir
+	detach(cmd)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+os.ExpandEnv(v))
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	c := &stdioConn{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  &tailBuffer{max: 4096},
+		pending: map[int64]chan rpcResult{},
+		done:    make(chan struct{}),
+	}
+	cmd.Stderr = c.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	go c.readLoop(bufio.NewReaderSize(stdout, 64*1024))
+	return c, nil
+}
+
+func (c *stdioConn) readLoop(r *bufio.Reader) {
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var m message
+			if json.Unmarshal(line, &m) == nil {
+				c.dispatch(m)
+			}
+		}
+		if err != nil {
+			werr := c.cmd.Wait()
+			msg := "server exited"
+			if werr != nil {
+				msg += ": " + werr.Error()
+			}
+			if tail := c.stderr.String(); tail != "" {
+				msg += "\nstderr: " + tail
+			}
+			c.fail(errors.New(msg))
+			return
+		}
+	}
+}
+
+func (c *stdioConn) dispatch(m message) {
+	switch {
+	case m.isResponse():
+		id, err := strconv.ParseInt(string(m.ID), 10, 64)
+		if err != nil {
+			return
+		}
+		c.mu.Lock()
+		ch := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- rpcResult{msg: m}
+		}
+	case m.isRequest():
+		_ = c.send(answerServerRequest(m))
+	}
+	// Notifications (progress, list_changed, logging) are ignored.
+}
+
+// fail marks the connection lost and fails pending calls.
+func (c *stdioConn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = &connError{err}
+	for id, ch := range c.pending {
+		ch <- rpcResult{err: c.err}
+		delete(c.pending, id)
+	}
+	close(c.done)
+}
+
+func (c *stdioConn) send(m message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err = c.stdin.Write(append(b, '\n'))
+	return err
+}
+
+func (c *stdioConn) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan rpcResult, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	req, err := newRequest(id, method, params)
+	if err != nil {
+		c.forget(id)
+		return nil, err
+	}
+	if err := c.send(req); err != nil {
+		c.forget(id)
+		return nil, &connError{err}
+	}
+
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			return nil, r.err
+		}
+		if r.msg.Error != nil {
+			return nil, r.msg.Error
+		}
+		return r.msg.Result, nil
+	case <-ctx.Done():
+		c.forget(id)
+		_ = c.notify(context.Background(), "notifications/cancelled", map[string]any{"requestId": id, "reason": ctx.Err().Error()})
+		return nil, ctx.Err()
+	}
+}
+
+func (c *stdioConn) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *stdioConn) notify(ctx context.Context, method string, params any) error {
+	m, err := newNotification(method, params)
+	if err != nil {
+		return err
+	}
+	if err := c.send(m); err != nil {
+		return &connError{err}
+	}
+	return nil
+}
+
+func (c *stdioConn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes stdin, which MCP servers treat as a request to exit, and kills
+// the server's process group if it has not exited after two seconds.
+func (c *stdioConn) close() error {
+	_ = c.stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(2 * time.Second):
+		_ = kill(c.cmd)
+		<-c.done
+	}
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written, for error messages.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(bytes.TrimSpace(b.buf))
+}
+
+func expandAll(ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = os.ExpandEnv(s)
+	}
+	return out
+}
@@ -0,0 +1,19 @@
+//go:build linux
+
+package mcp
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the server in its own process group, so a Ctrl-C in the
+// terminal reaches only rlmkit, and kills it if rlmkit dies.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+}
+
+// kill kills the server's process group, including any children it started.
+func kill(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package mcp
+
+import "os/exec"
+
+func detach(cmd *exec.Cmd) {}
+
+func kill(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
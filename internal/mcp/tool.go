@@ -0,0 +1,171 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+// RemoteTool exposes one MCP server tool as a core.Tool. Its name is
+// "<server>__<tool>" so remote tools cannot shadow built-ins or each other.
+type RemoteTool struct {
+	client *Client
+	tool   Tool
+	name   string
+}
+
+func NewRemoteTool(c *Client, t Tool) *RemoteTool {
+	return &RemoteTool{client: c, tool: t, name: toolName(c.Name(), t.Name)}
+}
+
+func (t *RemoteTool) Name() string { return t.name }
+func (t *RemoteTool) Description() string {
+	d := t.tool.Description
+	if d == "" {
+		d = t.tool.Title
+	}
+	if d == "" {
+		return fmt.Sprintf("Tool %s from MCP server %s.", t.tool.Name, t.client.Name())
+	}
+	return fmt.Sprintf("%s (MCP server %s)", d, t.client.Name())
+}
+func (t *RemoteTool) InputSchema() any {
+	if len(t.tool.InputSchema) == 0 || string(t.tool.InputSchema) == "null" {
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return t.tool.InputSchema
+}
+
+// Mutating follows the readOnlyHint annotation; tools that do not declare
+// themselves read-only are assumed to have side effects.
+func (t *RemoteTool) Mutating() bool {
+	a := t.tool.Annotations
+	return a == nil || a.ReadOnlyHint == nil || !*a.ReadOnlyHint
+}
+
+func (t *RemoteTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	if len(in) == 0 || string(in) == "null" {
+		in = json.RawMessage("{}")
+	}
+	res, err := t.client.CallTool(ctx, t.tool.Name, in)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	text := res.Text()
+	if res.IsError {
+		if text == "" {
+			text = "tool reported an error"
+		}
+		return core.ToolResult{}, errors.New(text)
+	}
+	meta := map[string]any{"server": t.client.Name(), "tool": t.tool.Name}
+	if len(res.StructuredContent) > 0 {
+		meta["structured"] = res.StructuredContent
+	}
+	return core.ToolResult{Content: text, Metadata: meta}, nil
+}
+
+var invalidToolChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// toolName builds a name model APIs accept (^[a-zA-Z0-9_-]{1,64}$).
+func toolName(server, tool string) string {
+	name := invalidToolChars.ReplaceAllString(server+"__"+tool, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// uniqueToolName returns name, or name with a "_2", "_3", ... suffix when
+// sanitizing or truncation made it collide with a registered tool; the
+// registry would otherwise list one tool and run the other.
+func uniqueToolName(r *core.Registry, name string) string {
+	if _, taken := r.Get(name); !taken {
+		return name
+	}
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf("_%d", n)
+		base := name
+		if len(base)+len(suffix) > 64 {
+			base = base[:64-len(suffix)]
+		}
+		if _, taken := r.Get(base + suffix); !taken {
+			return base + suffix
+		}
+	}
+}
+
+// Register connects to the enabled servers concurrently, lists their tools
+// and registers them in r. A server that fails to start is reported through
+// warn and skipped; the others are still registered. Stdio servers are child
+// processes in their own process group that exit when rlmkit does (their
+// stdin closes).
+func Register(ctx context.Context, r *core.Registry, servers map[string]ServerConfig, warn func(server string, err error)) {
+	names := make([]string, 0, len(servers))
+	for name, cfg := range servers {
+		if !cfg.Disabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	type result struct {
+		client *Client
+		tools  []Tool
+		err    error
+	}
+	results := make([]result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			cfg := servers[name]
+			c, err := NewClient(name, cfg)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			timeout := time.Duration(cfg.TimeoutSec) * time.Second
+			if timeout <= 0 {
+				timeout = 30 * time.Second
+			}
+			tctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			tools, err := c.ListTools(tctx)
+			if err != nil {
+				_ = c.Close()
+				results[i].err = err
+				return
+			}
+			results[i] = result{client: c, tools: tools}
+		}(i, name)
+	}
+	wg.Wait()
+
+	for i, res := range results {
+		if res.err != nil {
+			warn(names[i], res.err)
+			continue
+		}
+		allowed := map[string]bool{}
+		for _, n := range servers[names[i]].Tools {
+			allowed[n] = true
+		}
+		for _, t := range res.tools {
+			if len(allowed) > 0 && !allowed[t.Name] {
+				continue
+			}
+			rt := NewRemoteTool(res.client, t)
+			rt.name = uniqueToolName(r, rt.name)
+			r.Register(rt)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HunkResult reports how one hunk was applied (or why it was not).
+type HunkResult struct {
+	File    string `json:"file"`
+	Hunk    int    `json:"hunk"` // 1-based
+	Applied bool   `json:"applied"`
+	// Line is where the hunk was applied (1-based), or the nearest partial match on failure.
+	Line int `json:"line,omitempty"`
+	// Offset is Line minus the line number in the hunk header (when it had one).
+	Offset int `json:"offset,omitempty"`
+	// Match is "exact", "whitespace" or "fuzz=N" (N context lines ignored at each end).
+	Match  string `json:"match,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (r HunkResult) String() string {
+	if r.Applied {
+		s := fmt.Sprintf("%s: hunk #%d applied at line %d (%s", r.File, r.Hunk, r.Line, r.Match)
+		if r.Offset != 0 {
+			s += fmt.Sprintf(", offset %+d", r.Offset)
+		}
+		return s + ")"
+	}
+	s := fmt.Sprintf("%s: hunk #%d FAILED", r.File, r.Hunk)
+	if r.Detail != "" {
+		s += ": " + r.Detail
+	}
+	return s
+}
+
+// ErrHunksFailed is returned by Apply when at least one hunk could not be placed.
+var ErrHunksFailed = errors.New("one or more hunks failed to apply")
+
+// Resolver maps a patch path to an absolute filesystem path (e.g. with root checks).
+type Resolver func(rel string) (string, error)
+
+// Apply applies the file patches through resolve. It is all-or-nothing: files
+// are only written if every hunk in every file can be placed. The per-hunk
+// results are returned in both cases.
+func Apply(files []FilePatch, resolve Resolver) ([]HunkResult, error) {
+	type pending struct {
+		oldAbs, newAbs string
+		content        string
+		remove         bool
+	}
+	var results []HunkResult
+	var writes []pending
+	failed := false
+
+	for _, f := range files {
+		var oldAbs, newAbs string
+		var err error
+		if f.OldPath != "" {
+			if oldAbs, err = resolve(f.OldPath); err != nil {
+				return results, fmt.Errorf("%s: %w", f.OldPath, err)
+			}
+		}
+		if f.NewPath != "" {
+			if newAbs, err = resolve(f.NewPath); err != nil {
+				return results, fmt.Errorf("%s: %w", f.NewPath, err)
+			}
+		}
+
+		var content string
+		if oldAbs != "" {
+			b, err := os.ReadFile(oldAbs)
+			if err != nil {
+				return results, err
+			}
+			content = string(b)
+		} else if newAbs != "" {
+			if _, err := os.Stat(newAbs); err == nil {
+				return results, fmt.Errorf("%s: file already exists", f.NewPath)
+			}
+		}
+
+		out, res := applyFile(f, content)
+		results = append(results, res...)
+		for _, r := range res {
+			if !r.Applied {
+				failed = true
+			}
+		}
+		writes = append(writes, pending{oldAbs: oldAbs, newAbs: newAbs, content: out, remove: newAbs == ""})
+	}
+
+	if failed {
+		return results, ErrHunksFailed
+	}
+
+	for _, w := range writes {
+		if w.remove {
+			if err := os.Remove(w.oldAbs); err != nil {
+				return results, err
+			}
+			continue
+		}
+		mode := os.FileMode(0o644)
+		if w.oldAbs != "" {
+			if info, err := os.Stat(w.oldAbs); err == nil {
+				mode = info.Mode().Perm()
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(w.newAbs), 0o755); err != nil {
+			return results, err
+		}
+		if err := os.WriteFile(w.newAbs, []byte(w.content), mode); err != nil {
+			return results, err
+		}
+		if w.oldAbs != "" && w.oldAbs != w.newAbs {
+			if err := os.Remove(w.oldAbs); err != nil {
+				return results, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// applyFile applies hunks to content in order and returns the new content.
+func applyFile(f FilePatch, content string) (string, []HunkResult) {
+	crlf := strings.Contains(content, "\r\n")
+	if crlf {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	trailingNL := content == "" || strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	var results []HunkResult
+	offset := 0 // net lines added by earlier hunks
+	minPos := 0 // hunks must apply in order
+	for hi, h := range f.Hunks {
+		res := HunkResult{File: f.Path(), Hunk: hi + 1}
+
+		oldLines := oldSide(h.Lines)
+
+		// The hunk starts at old line OldStart, except that a pure insertion
+		// ("-N,0") goes after line N: "-0,0" is the top of the file.
+		anchor := h.OldStart - 1
+		if len(oldLines) == 0 {
+			anchor = h.OldStart
+		}
+		known := h.Numbered && anchor >= 0
+		expected := -1 // unknown: search, or append an insertion
+		if known {
+			expected = anchor + offset
+		}
+
+		pos, trimHead, trimTail, match := locate(lines, h.Lines, expected, minPos)
+		if pos < 0 {
+			near, score := nearest(lines, oldLines, minPos)
+			res.Detail = "context not found"
+			if near >= 0 && score > 0 {
+				res.Line = near + 1
+				res.Detail = fmt.Sprintf("context not found; nearest partial match at line %d (%d/%d lines)", near+1, score, len(oldLines))
+			}
+			results = append(results, res)
+			continue
+		}
+
+		// Build the replacement, keeping the file's own text for context lines
+		// (so whitespace-insensitive matches don't rewrite them).
+		body := trimContext(h.Lines, trimHead, trimTail)
+		var repl []string
+		fi := pos
+		for _, l := range body {
+			switch l.Op {
+			case ' ':
+				repl = append(repl, lines[fi])
+				fi++
+			case '-':
+				fi++
+			case '+':
+				repl = append(repl, l.Text)
+			}
+		}
+		consumed := fi - pos
+
+		newLines := make([]string, 0, len(lines)-consumed+len(repl))
+		newLines = append(newLines, lines[:pos]...)
+		newLines = append(newLines, repl...)
+		newLines = append(newLines, lines[pos+consumed:]...)
+		lines = newLines
+
+		res.Applied = true
+		res.Line = pos + 1
+		res.Match = match
+		if known {
+			res.Offset = pos - trimHead - (anchor + offset)
+		}
+		results = append(results, res)
+
+		offset += len(repl) - consumed
+		minPos = pos + len(repl)
+	}
+
+	out := strings.Join(lines, "\n")
+	if len(lines) > 0 && trailingNL {
+		out += "\n"
+	}
+	if crlf {
+		out = strings.ReplaceAll(out, "\n", "\r\n")
+	}
+	return out, results
+}
+
+// locate finds where the hunk's old side appears in lines at or after minPos.
+// It tries, in order: exact match nearest to expected, whitespace-insensitive
+// match, then dropping up to two context lines at each end (fuzz). It returns
+// the match position and how many leading/trailing context lines were dropped.
+func locate(lines []string, body []Line, expected, minPos int) (pos, trimHead, trimTail int, match string) {
+	old := oldSide(body)
+	if len(old) == 0 {
+		// Pure insertion: trust the header, else append.
+		p := expected
+		if p < minPos || p > len(lines) {
+			p = len(lines)
+		}
+		return p, 0, 0, "exact"
+	}
+	if p := search(lines, old, expected, minPos, exactEq); p >= 0 {
+		return p, 0, 0, "exact"
+	}
+	if p := search(lines, old, expected, minPos, wsEq); p >= 0 {
+		return p, 0, 0, "whitespace"
+	}
+	for fuzz := 1; fuzz <= 2; fuzz++ {
+		head, tail := contextRun(body, fuzz, false), contextRun(body, fuzz, true)
+		if head == 0 && tail == 0 {
+			break
+		}
+		trimmed := oldSide(trimContext(body, head, tail))
+		if len(trimmed) == 0 {
+			break
+		}
+		exp := expected
+		if exp >= 0 {
+			exp += head
+		}
+		if p := search(lines, trimmed, exp, minPos, wsEq); p >= 0 {
+			return p, head, tail, fmt.Sprintf("fuzz=%d", fuzz)
+		}
+	}
+	return -1, 0, 0, ""
+}
+
+// contextRun counts leading (or trailing) context lines in body, up to max.
+func contextRun(body []Line, max int, fromEnd bool) int {
+	n := 0
+	for i := 0; i < len(body) && n < max; i++ {
+		l := body[i]
+		if fromEnd {
+			l = body[len(body)-1-i]
+		}
+		if l.Op != ' ' {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+func oldSide(body []Line) []string {
+	var old []string
+	for _, l := range body {
+		if l.Op != '+' {
+			old = append(old, l.Text)
+		}
+	}
+	return old
+}
+
+// search returns the match position closest to expected (or the first one when
+// expected is unknown), or -1.
+func search(lines, old []string, expected, minPos int, eq func(a, b string) bool) int {
+	best := -1
+	for p := minPos; p+len(old) <= len(lines); p++ {
+		if !matchAt(lines, old, p, eq) {
+			continue
+		}
+		if expected < 0 {
+			return p
+		}
+		if best < 0 || abs(p-expected) < abs(best-expected) {
+			best = p
+		}
+	}
+	return best
+}
+
+func matchAt(lines, old []string, p int, eq func(a, b string) bool) bool {
+	for i, o := range old {
+		if !eq(lines[p+i], o) {
+			return false
+		}
+	}
+	return true
+}
+
+// nearest returns the position with the most whitespace-insensitive line matches.
+func nearest(lines, old []string, minPos int) (int, int) {
+	best, bestScore := -1, 0
+	for p := minPos; p < len(lines); p++ {
+		score := 0
+		for i, o := range old {
+			if p+i < len(lines) && wsEq(lines[p+i], o) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	return best, bestScore
+}
+
+// trimContext drops up to head leading and tail trailing context lines.
+func trimContext(body []Line, head, tail int) []Line {
+	for head > 0 && len(body) > 0 && body[0].Op == ' ' {
+		body = body[1:]
+		head--
+	}
+	for tail > 0 && len(body) > 0 && body[len(body)-1].Op == ' ' {
+		body = body[:len(body)-1]
+		tail--
+	}
+	return body
+}
+
+func exactEq(a, b string) bool { return a == b }
+
+func wsEq(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,25 @@
+package patch
+
+import "testing"
+
+func TestApplyFileInsertion(t *testing.T) {
+	cases := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"top of file", "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+new1\n+new2\n", "new1\nnew2\nl1\nl2\nl3\n"},
+		{"after line 2", "--- a/f\n+++ b/f\n@@ -2,0 +3 @@\n+new\n", "l1\nl2\nnew\nl3\n"},
+		{"unknown position", "--- a/f\n+++ b/f\n@@ @@\n+new\n", "l1\nl2\nl3\nnew\n"},
+	}
+	for _, c := range cases {
+		files, err := Parse(c.patch)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		got, res := applyFile(files[0], "l1\nl2\nl3\n")
+		if got != c.want {
+			t.Errorf("%s: got %q, want %q (%v)", c.name, got, c.want, res)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package patch is a forgiving pure-Go unified diff applier.
+//
+// It is used when `git apply` rejects a patch (typically whitespace or
+// line-number drift in model-written diffs) and in non-git directories.
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FilePatch is the set of hunks for one file.
+type FilePatch struct {
+	OldPath string // "" for a new file
+	NewPath string // "" for a deleted file
+	Hunks   []Hunk
+}
+
+// Hunk is one @@ section. Numbered reports whether the header had an old
+// line number; OldStart is 0 when it did not (and for "-0,0" when it did).
+type Hunk struct {
+	OldStart int
+	NewStart int
+	Numbered bool
+	Lines    []Line
+}
+
+type Line struct {
+	Op   byte // ' ', '-', '+'
+	Text string
+}
+
+// Path returns the path the patch applies to.
+func (f FilePatch) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Parse parses a unified diff. It accepts git-style and plain diffs, hunk
+// headers without line numbers ("@@" or "@@ ... @@"), and blank context
+// lines whose leading space was dropped.
+func Parse(diff string) ([]FilePatch, error) {
+	diff = strings.ReplaceAll(diff, "\r\n", "\n")
+	lines := strings.Split(diff, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var files []FilePatch
+	var cur *FilePatch
+	flush := func() {
+		if cur != nil {
+			files = append(files, *cur)
+			cur = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			flush()
+			cur = &FilePatch{}
+			if a, b, ok := parseGitHeader(l); ok {
+				cur.OldPath, cur.NewPath = a, b
+			}
+		case strings.HasPrefix(l, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if cur == nil || len(cur.Hunks) > 0 {
+				flush()
+				cur = &FilePatch{}
+			}
+			cur.OldPath = headerPath(l[4:])
+			cur.NewPath = headerPath(lines[i+1][4:])
+			i++
+		case strings.HasPrefix(l, "new file mode"):
+			if cur != nil {
+				cur.OldPath = ""
+			}
+		case strings.HasPrefix(l, "deleted file mode"):
+			if cur != nil {
+				cur.NewPath = ""
+			}
+		case strings.HasPrefix(l, "rename from "):
+			if cur != nil {
+				cur.OldPath = strings.TrimSpace(l[len("rename from "):])
+			}
+		case strings.HasPrefix(l, "rename to "):
+			if cur != nil {
+				cur.NewPath = strings.TrimSpace(l[len("rename to "):])
+			}
+		case strings.HasPrefix(l, "@@"):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: hunk without a file header (--- / +++)", i+1)
+			}
+			h := parseHunkHeader(l)
+			i = readHunkBody(lines, i+1, &h) - 1
+			cur.Hunks = append(cur.Hunks, h)
+		}
+	}
+	flush()
+
+	var out []FilePatch
+	for _, f := range files {
+		if f.OldPath == "" && f.NewPath == "" {
+			continue
+		}
+		out = append(out, f)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no file changes found in patch")
+	}
+	return out, nil
+}
+
+// readHunkBody reads hunk lines starting at i and returns the index of the
+// first line after the hunk. Header counts are ignored because model-written
+// diffs often get them wrong; the hunk ends at the next header or at the first
+// line that is not part of a diff.
+func readHunkBody(lines []string, i int, h *Hunk) int {
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		if strings.HasPrefix(l, "@@") || strings.HasPrefix(l, "diff --git ") ||
+			(strings.HasPrefix(l, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")) {
+			break
+		}
+		if l == "" {
+			h.Lines = append(h.Lines, Line{Op: ' '})
+			continue
+		}
+		switch l[0] {
+		case ' ', '-', '+':
+			h.Lines = append(h.Lines, Line{Op: l[0], Text: l[1:]})
+			continue
+		case '\\':
+			// "\ No newline at end of file"
+			continue
+		}
+		break
+	}
+	// Blank lines separating files are not context.
+	for len(h.Lines) > 0 {
+		last := h.Lines[len(h.Lines)-1]
+		if last.Op != ' ' || last.Text != "" {
+			break
+		}
+		h.Lines = h.Lines[:len(h.Lines)-1]
+	}
+	return i
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@". Missing numbers yield OldStart 0
+// and Numbered false.
+func parseHunkHeader(l string) Hunk {
+	h := Hunk{}
+	fields := strings.Fields(strings.TrimPrefix(l, "@@"))
+	for _, f := range fields {
+		if f == "@@" {
+			break
+		}
+		switch f[0] {
+		case '-':
+			h.OldStart, h.Numbered = parseRangeStart(f[1:])
+		case '+':
+			h.NewStart, _ = parseRangeStart(f[1:])
+		}
+	}
+	return h
+}
+
+func parseRangeStart(s string) (int, bool) {
+	startS, _, _ := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startS)
+	if err != nil || start < 0 {
+		return 0, false
+	}
+	return start, true
+}
+
+func parseGitHeader(l string) (string, string, bool) {
+	rest := strings.TrimPrefix(l, "diff --git ")
+	i := strings.Index(rest, " b/")
+	if i < 0 || !strings.HasPrefix(rest, "a/") {
+		return "", "", false
+	}
+	return rest[2:i], rest[i+3:], true
+}
+
+func headerPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
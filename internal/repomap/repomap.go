@@ -0,0 +1,262 @@
+// Package repomap builds a compact, token-budgeted outline of a repository
+// (directories, files and their most important top-level symbols) for the
+// system prompt.
+//
+// Go symbols come from internal/gosym and are ranked by how often they are
+// referenced; other files are listed by name only. Maps are cached in
+// <repo>/.rlmkit/repomap.txt, keyed by git HEAD and the token budget.
+package repomap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/ignore"
+)
+
+// DefaultTokens is the budget used when Options.MaxTokens is zero.
+const DefaultTokens = 1024
+
+// cacheVersion is bumped when the output format changes.
+const cacheVersion = 2
+
+type Options struct {
+	MaxTokens int
+	// NoCache disables reading and writing the on-disk cache.
+	NoCache bool
+}
+
+// Load returns the repo map for root, from the cache when git HEAD and the
+// budget are unchanged, otherwise by generating (and caching) it.
+func Load(ctx context.Context, root string, opts Options) (string, error) {
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultTokens
+	}
+	cachePath := filepath.Join(root, ".rlmkit", "repomap.txt")
+	key := ""
+	if !opts.NoCache {
+		if head := gitHead(ctx, root); head != "" {
+			key = fmt.Sprintf("# rlmkit repomap v%d head=%s tokens=%d", cacheVersion, head, opts.MaxTokens)
+		}
+	}
+	if key != "" {
+		if b, err := os.ReadFile(cachePath); err == nil {
+			if first, rest, ok := strings.Cut(string(b), "\n"); ok && first == key {
+				return rest, nil
+			}
+		}
+	}
+
+	m, err := Generate(ctx, root, opts.MaxTokens)
+	if err != nil {
+		return "", err
+	}
+	if key != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+			_ = os.WriteFile(cachePath, []byte(key+"\n"+m), 0o644)
+		}
+	}
+	return m, nil
+}
+
+func gitHead(ctx context.Context, root string) string {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+type entry struct {
+	file  string
+	label string // "" for a file listed by name only
+	score float64
+}
+
+// Generate builds the map within roughly maxTokens tokens (estimated at four
+// characters per token).
+func Generate(ctx context.Context, root string, maxTokens int) (string, error) {
+	if maxTokens <= 0 {
+		maxTokens = DefaultTokens
+	}
+	var files []string
+	err := ignore.New(root).Walk(ctx, "", func(rel string, d fs.DirEntry) error {
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", errors.New("no files found")
+	}
+
+	fileScore := map[string]float64{}
+	var entries []entry
+	if idx, err := gosym.NewCache(root).Index(ctx); err == nil {
+		for _, d := range idx.Decls() {
+			if d.Kind == "field" || d.Kind == "interface method" {
+				continue
+			}
+			file := idx.Location(d.Pos).File
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			score := 1 + float64(len(idx.References(d)))
+			if !isExported(d.Name) {
+				score *= 0.3
+			}
+			if d.Kind == "type" {
+				score *= 1.5
+			}
+			entries = append(entries, entry{file: file, label: label(d), score: score})
+			fileScore[file] += score
+		}
+	}
+	for _, f := range files {
+		// Files rank by their symbols; top-level files (README, go.mod, ...)
+		// get a boost since they orient the reader.
+		score := fileScore[f] * 0.01
+		if !strings.Contains(f, "/") {
+			score += 0.5
+		}
+		entries = append(entries, entry{file: f, score: score})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].score != entries[j].score {
+			return entries[i].score > entries[j].score
+		}
+		return entries[i].file < entries[j].file
+	})
+
+	// Greedily add entries by rank while the rendered size fits the budget.
+	// A directory's header is charged with its first shown file; directories
+	// with none are summed up in one line, which is reserved up front.
+	budget := maxTokens*4 - moreDirsLen
+	shownFiles := map[string]bool{}
+	shownDirs := map[string]bool{}
+	symbols := map[string][]string{}
+	size := 0
+	for _, e := range entries {
+		cost := 0
+		dir := path.Dir(e.file)
+		if !shownDirs[dir] {
+			cost += len(dir) + dirHeaderExtra
+		}
+		if !shownFiles[e.file] {
+			cost += len(e.file) + 4
+		}
+		if e.label != "" {
+			cost += len(e.label) + 2
+		}
+		if size+cost > budget {
+			continue
+		}
+		size += cost
+		shownDirs[dir] = true
+		shownFiles[e.file] = true
+		if e.label != "" {
+			symbols[e.file] = append(symbols[e.file], e.label)
+		}
+	}
+
+	return render(files, shownFiles, symbols), nil
+}
+
+// dirHeaderExtra is the most a directory header adds to the directory name
+// ("/ (+1234 more files)\n"); moreDirsLen bounds the closing summary line.
+const (
+	dirHeaderExtra = 22
+	moreDirsLen    = 48
+)
+
+// render prints shown files grouped by directory, with a count of the left
+// out files of each, and one line counting the directories left out.
+func render(files []string, shown map[string]bool, symbols map[string][]string) string {
+	byDir := map[string][]string{}
+	var dirs []string
+	for _, f := range files {
+		dir := path.Dir(f)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], f)
+	}
+	sort.Strings(dirs)
+
+	var sb strings.Builder
+	w := &sb
+	moreDirs, moreFiles := 0, 0
+	for _, dir := range dirs {
+		var names []string
+		hidden := 0
+		for _, f := range byDir[dir] {
+			if !shown[f] {
+				hidden++
+				continue
+			}
+			name := path.Base(f)
+			if syms := symbols[f]; len(syms) > 0 {
+				name += ": " + strings.Join(syms, ", ")
+			}
+			names = append(names, name)
+		}
+		header := dir + "/"
+		if dir == "." {
+			header = "./"
+		}
+		if len(names) == 0 {
+			moreDirs++
+			moreFiles += hidden
+			continue
+		}
+		if hidden > 0 {
+			fmt.Fprintf(w, "%s (+%d more files)\n", header, hidden)
+		} else {
+			fmt.Fprintf(w, "%s\n", header)
+		}
+		for _, n := range names {
+			fmt.Fprintf(w, "  %s\n", n)
+		}
+	}
+	if moreDirs > 0 {
+		fmt.Fprintf(w, "… %d more dirs (%d files)\n", moreDirs, moreFiles)
+	}
+	return sb.String()
+}
+
+func label(d *gosym.Decl) string {
+	switch d.Kind {
+	case "type":
+		return "type " + d.Name
+	case "func":
+		return d.Name + "()"
+	case "method":
+		return d.Recv + "." + d.Name + "()"
+	default:
+		return d.Kind + " " + d.Name
+	}
+}
+
+func isExported(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
@@ -0,0 +1,42 @@
+package repomap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateManyDirs(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		dir := filepath.Join(root, "pkg", fmt.Sprintf("component%03d", i))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		src := fmt.Sprintf("package component%03d\n\ntype Widget%d struct{}\n\nfunc NewWidget%d() *Widget%d { return nil }\n", i, i, i, i)
+		if err := os.WriteFile(filepath.Join(dir, "widget.go"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const maxTokens = 100
+	out, err := Generate(context.Background(), root, maxTokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > maxTokens*4 {
+		t.Errorf("map is %d chars, budget %d:\n%s", len(out), maxTokens*4, out)
+	}
+	if !strings.Contains(out, "more dirs") {
+		t.Errorf("no collapsed directory line:\n%s", out)
+	}
+	if !strings.Contains(out, "Widget") {
+		t.Errorf("no symbols shown:\n%s", out)
+	}
+}
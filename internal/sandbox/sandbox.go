@@ -0,0 +1,76 @@
+// Package sandbox optionally confines commands started by tools.
+//
+// On Linux, a sandboxed command sees the repo (and any extra writable paths)
+// read-write, the rest of the filesystem read-only, no network unless allowed,
+// and optional CPU/memory/process rlimits. bubblewrap (bwrap) is used when
+// present; otherwise rlmkit sets up user/mount/network namespaces itself by
+// re-executing its own binary as a small helper.
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config is the per-tool sandbox configuration (rlmkit.json "sandbox" map).
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Backend is "auto" (default: bwrap if present, else namespaces), "bwrap" or "namespaces".
+	Backend string `json:"backend,omitempty"`
+	// Network allows network access inside the sandbox.
+	Network bool `json:"network,omitempty"`
+	// WritablePaths are extra absolute paths mounted read-write (e.g. a Go build cache).
+	WritablePaths []string `json:"writable_paths,omitempty"`
+	CPUSeconds    uint64   `json:"cpu_seconds,omitempty"`
+	MemoryMB      uint64   `json:"memory_mb,omitempty"`
+	MaxProcesses  uint64   `json:"max_processes,omitempty"`
+}
+
+// HelperArg is the hidden argv[1] used when rlmkit re-executes itself inside the sandbox.
+const HelperArg = "__sandbox-exec"
+
+var ErrUnsupported = errors.New("sandbox is only supported on linux")
+
+// helperSpec is passed to the helper process as a JSON argument.
+type helperSpec struct {
+	SetupMounts bool     `json:"setup_mounts,omitempty"`
+	Writable    []string `json:"writable,omitempty"`
+	CPUSeconds  uint64   `json:"cpu_seconds,omitempty"`
+	MemoryMB    uint64   `json:"memory_mb,omitempty"`
+	MaxProcs    uint64   `json:"max_processes,omitempty"`
+}
+
+func (s helperSpec) hasLimits() bool {
+	return s.CPUSeconds > 0 || s.MemoryMB > 0 || s.MaxProcs > 0
+}
+
+// RunHelper is the entry point for the re-executed helper. args are everything
+// after HelperArg: <spec json> -- <command> [args...]. It only returns on error.
+func RunHelper(args []string) {
+	if err := runHelper(args); err != nil {
+		fmt.Fprintln(os.Stderr, "sandbox:", err)
+		os.Exit(126)
+	}
+}
+
+func parseHelperArgs(args []string) (helperSpec, []string, error) {
+	if len(args) < 3 || args[1] != "--" {
+		return helperSpec{}, nil, errors.New("usage: " + HelperArg + " <spec> -- <command> [args...]")
+	}
+	var spec helperSpec
+	if err := json.Unmarshal([]byte(args[0]), &spec); err != nil {
+		return helperSpec{}, nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	return spec, args[2:], nil
+}
+
+func normalizeBackend(b string) string {
+	b = strings.ToLower(strings.TrimSpace(b))
+	if b == "" {
+		return "auto"
+	}
+	return b
+}
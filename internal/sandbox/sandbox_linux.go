@@ -0,0 +1,319 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// RLIMIT_NPROC is not exported by package syscall.
+const rlimitNproc = 6
+
+// Wrap rewrites cmd in place so that it runs inside the sandbox described by cfg.
+// It must be called after the command is constructed and before it is started.
+func Wrap(cmd *exec.Cmd, cfg Config, repoRoot string) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	root, err := filepath.Abs(repoRoot)
+	if err != nil {
+		return err
+	}
+	writable := []string{root}
+	for _, p := range cfg.WritablePaths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("sandbox writable path must be absolute: %s", p)
+		}
+		writable = append(writable, filepath.Clean(p))
+	}
+
+	argv := append([]string{}, cmd.Args...)
+	if cmd.Path != "" && filepath.IsAbs(cmd.Path) {
+		argv[0] = cmd.Path
+	}
+
+	spec := helperSpec{
+		Writable:   writable,
+		CPUSeconds: cfg.CPUSeconds,
+		MemoryMB:   cfg.MemoryMB,
+		MaxProcs:   cfg.MaxProcesses,
+	}
+
+	switch normalizeBackend(cfg.Backend) {
+	case "auto":
+		if bwrap, err := exec.LookPath("bwrap"); err == nil {
+			return wrapBwrap(cmd, bwrap, spec, argv, cfg.Network)
+		}
+		return wrapNamespaces(cmd, spec, argv, cfg.Network)
+	case "bwrap":
+		bwrap, err := exec.LookPath("bwrap")
+		if err != nil {
+			return fmt.Errorf("sandbox backend bwrap: %w", err)
+		}
+		return wrapBwrap(cmd, bwrap, spec, argv, cfg.Network)
+	case "namespaces":
+		return wrapNamespaces(cmd, spec, argv, cfg.Network)
+	default:
+		return fmt.Errorf("unknown sandbox backend %q", cfg.Backend)
+	}
+}
+
+func wrapBwrap(cmd *exec.Cmd, bwrap string, spec helperSpec, argv []string, network bool) error {
+	args := []string{bwrap, "--die-with-parent", "--unshare-all"}
+	if network {
+		args = append(args, "--share-net")
+	}
+	args = append(args,
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	)
+	for _, p := range spec.Writable {
+		args = append(args, "--bind", p, p)
+	}
+	if cmd.Dir != "" {
+		args = append(args, "--chdir", cmd.Dir)
+	}
+	args = append(args, "--")
+
+	if spec.hasLimits() {
+		// bwrap has no rlimit support; chain through our helper to set them.
+		helper, err := helperArgv(spec)
+		if err != nil {
+			return err
+		}
+		// The helper binary may live under /tmp (go run); keep it visible.
+		args = append(args[:len(args)-1], "--ro-bind", helper[0], helper[0], "--")
+		args = append(args, helper...)
+	}
+	args = append(args, argv...)
+
+	cmd.Path = bwrap
+	cmd.Args = args
+	cmd.Err = nil
+	return nil
+}
+
+func wrapNamespaces(cmd *exec.Cmd, spec helperSpec, argv []string, network bool) error {
+	spec.SetupMounts = true
+	helper, err := helperArgv(spec)
+	if err != nil {
+		return err
+	}
+
+	flags := uintptr(syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS)
+	if !network {
+		flags |= syscall.CLONE_NEWNET
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags |= flags
+	// Map the caller to root inside the namespace so the helper keeps the
+	// capabilities it needs to set up mounts across execve.
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	cmd.SysProcAttr.Pdeathsig = syscall.SIGKILL
+
+	cmd.Path = helper[0]
+	cmd.Args = append(helper, argv...)
+	cmd.Err = nil
+	return nil
+}
+
+func helperArgv(spec helperSpec) ([]string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: cannot locate rlmkit binary: %w", err)
+	}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	return []string{self, HelperArg, string(b), "--"}, nil
+}
+
+func runHelper(args []string) error {
+	spec, argv, err := parseHelperArgs(args)
+	if err != nil {
+		return err
+	}
+	if spec.SetupMounts {
+		if err := setupMounts(spec.Writable); err != nil {
+			return err
+		}
+	}
+	if err := setLimits(spec); err != nil {
+		return err
+	}
+
+	path := argv[0]
+	if !strings.Contains(path, "/") {
+		if path, err = exec.LookPath(path); err != nil {
+			return err
+		}
+	}
+	return syscall.Exec(path, argv, os.Environ())
+}
+
+func setLimits(spec helperSpec) error {
+	set := func(res int, v uint64, name string) error {
+		if v == 0 {
+			return nil
+		}
+		lim := syscall.Rlimit{Cur: v, Max: v}
+		if err := syscall.Setrlimit(res, &lim); err != nil {
+			return fmt.Errorf("setrlimit %s: %w", name, err)
+		}
+		return nil
+	}
+	if err := set(syscall.RLIMIT_CPU, spec.CPUSeconds, "cpu"); err != nil {
+		return err
+	}
+	if err := set(syscall.RLIMIT_AS, spec.MemoryMB*1024*1024, "memory"); err != nil {
+		return err
+	}
+	return set(rlimitNproc, spec.MaxProcs, "nproc")
+}
+
+// setupMounts runs inside fresh user+mount namespaces. It bind-mounts each
+// writable path onto itself, then remounts every other mount read-only.
+func setupMounts(writable []string) error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make / private: %w", err)
+	}
+
+	// Give the command a scratch /tmp unless a writable path lives there.
+	tmpInUse := false
+	for _, p := range writable {
+		if isUnder(p, "/tmp") {
+			tmpInUse = true
+		}
+	}
+	if !tmpInUse {
+		if err := syscall.Mount("tmpfs", "/tmp", "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=1777"); err != nil {
+			return fmt.Errorf("mount /tmp: %w", err)
+		}
+	}
+
+	for _, p := range writable {
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		if err := syscall.Mount(p, p, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("bind %s: %w", p, err)
+		}
+	}
+
+	mounts, err := readMountInfo()
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		if skipReadOnly(m.point, writable) {
+			continue
+		}
+		flags := uintptr(syscall.MS_BIND|syscall.MS_REMOUNT|syscall.MS_RDONLY) | m.locked
+		if err := syscall.Mount("", m.point, "", flags, ""); err != nil {
+			return fmt.Errorf("remount %s read-only: %w", m.point, err)
+		}
+	}
+	return nil
+}
+
+func skipReadOnly(point string, writable []string) bool {
+	for _, special := range []string{"/dev", "/proc", "/sys", "/tmp"} {
+		if isUnder(point, special) {
+			return true
+		}
+	}
+	for _, w := range writable {
+		if isUnder(point, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnder(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimRight(dir, "/")+"/")
+}
+
+type mountEntry struct {
+	point  string
+	locked uintptr
+}
+
+// readMountInfo parses /proc/self/mountinfo. Per-mount flags that the kernel
+// locks inside a user namespace must be preserved when remounting.
+func readMountInfo() ([]mountEntry, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []mountEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		m := mountEntry{point: unescapeMountPath(fields[4])}
+		for _, opt := range strings.Split(fields[5], ",") {
+			switch opt {
+			case "nosuid":
+				m.locked |= syscall.MS_NOSUID
+			case "nodev":
+				m.locked |= syscall.MS_NODEV
+			case "noexec":
+				m.locked |= syscall.MS_NOEXEC
+			case "noatime":
+				m.locked |= syscall.MS_NOATIME
+			case "nodiratime":
+				m.locked |= syscall.MS_NODIRATIME
+			case "relatime":
+				m.locked |= syscall.MS_RELATIME
+			case "strictatime":
+				m.locked |= syscall.MS_STRICTATIME
+			}
+		}
+		out = append(out, m)
+	}
+	return out, sc.Err()
+}
+
+// unescapeMountPath decodes the octal escapes (\040 etc.) used in mountinfo.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"os/exec"
+)
+
+// Wrap returns ErrUnsupported if the sandbox is enabled on a non-Linux platform.
+func Wrap(cmd *exec.Cmd, cfg Config, repoRoot string) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	return ErrUnsupported
+}
+
+func runHelper(args []string) error {
+	return ErrUnsupported
+}
@@ -43,9 +43,40 @@ type TurnRecord struct {
 	UserInput string           `json:"user_input"`
 	Assistant string           `json:"assistant"`
 	ToolCalls []ToolCallRecord `json:"tool_calls,omitempty"`
+	// CheckpointID identifies the working-tree snapshot taken before the
+	// turn's first mutating tool call (empty if the turn changed nothing).
+	CheckpointID string `json:"checkpoint_id,omitempty"`
+	// CheckpointError is why the checkpoint could not be taken; the turn's
+	// edits then cannot be undone.
+	CheckpointError string `json:"checkpoint_error,omitempty"`
+	// Reasoning is the model's reasoning text, stored only when enabled;
+	// Assistant never includes it.
+	Reasoning string `json:"reasoning,omitempty"`
+	// Interrupted marks a turn cancelled before its final answer; Assistant
+	// then holds the partial reply, if any.
+	Interrupted bool `json:"interrupted,omitempty"`
+	// Steering holds the messages sent into the turn while it ran.
+	Steering []string `json:"steering,omitempty"`
+}
+
+// UndoRecord marks a turn's checkpoint as restored.
+type UndoRecord struct {
+	Type         string    `json:"type"` // "undo"
+	SessionID    string    `json:"session_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	CheckpointID string    `json:"checkpoint_id"`
+	BackupID     string    `json:"backup_id,omitempty"`
 }
 
 func (s *Store) AppendTurn(ctx context.Context, rec TurnRecord) error {
+	return s.appendRecord(ctx, rec.SessionID, rec)
+}
+
+func (s *Store) AppendUndo(ctx context.Context, rec UndoRecord) error {
+	return s.appendRecord(ctx, rec.SessionID, rec)
+}
+
+func (s *Store) appendRecord(ctx context.Context, sessionID string, rec any) error {
 	if err := s.EnsureDir(); err != nil {
 		return err
 	}
@@ -56,7 +87,7 @@ func (s *Store) AppendTurn(ctx context.Context, rec TurnRecord) error {
 	default:
 	}
 
-	p := s.PathFor(rec.SessionID)
+	p := s.PathFor(sessionID)
 	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
 	if err != nil {
 		return err
@@ -218,6 +249,61 @@ func (s *Store) LoadRecentTurns(ctx context.Context, sessionID string, lastN int
 	return turns[len(turns)-lastN:], nil
 }
 
+// LastUndoableTurn returns the most recent turn with a checkpoint that has not
+// already been undone. Repeated undos therefore walk back one turn at a time.
+// A turn whose checkpoint failed (CheckpointError set, no CheckpointID) stops
+// the walk, so the caller can explain why it cannot be undone.
+func (s *Store) LastUndoableTurn(ctx context.Context, sessionID string) (TurnRecord, bool, error) {
+	p := s.PathFor(sessionID)
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return TurnRecord{}, false, nil
+		}
+		return TurnRecord{}, false, err
+	}
+	defer f.Close()
+
+	var stack []TurnRecord
+	sc := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	sc.Buffer(buf, 2*1024*1024)
+
+	for sc.Scan() {
+		select {
+		case <-ctx.Done():
+			return TurnRecord{}, false, ctx.Err()
+		default:
+		}
+
+		// Undo records share the type and checkpoint_id fields with turns.
+		var rec TurnRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		switch rec.Type {
+		case "turn":
+			if rec.CheckpointID != "" || rec.CheckpointError != "" {
+				stack = append(stack, rec)
+			}
+		case "undo":
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].CheckpointID == rec.CheckpointID {
+					stack = append(stack[:i], stack[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return TurnRecord{}, false, err
+	}
+	if len(stack) == 0 {
+		return TurnRecord{}, false, nil
+	}
+	return stack[len(stack)-1], true, nil
+}
+
 func truncate(s string, max int) string {
 	if max <= 0 || len(s) <= max {
 		return s
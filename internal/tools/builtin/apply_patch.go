@@ -4,26 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/answerlayer/rlmkit/internal/patch"
 	"github.com/answerlayer/rlmkit/internal/tools/core"
 )
 
 type ApplyPatchTool struct {
 	repoRoot string
+	guard    pathGuard
 }
 
-func NewApplyPatchTool(repoRoot string) *ApplyPatchTool {
-	return &ApplyPatchTool{repoRoot: repoRoot}
+func NewApplyPatchTool(repoRoot string, protected []string) *ApplyPatchTool {
+	return &ApplyPatchTool{repoRoot: repoRoot, guard: pathGuard{repoRoot: repoRoot, protected: protected}}
 }
 
 func (t *ApplyPatchTool) Name() string { return "apply_patch" }
 func (t *ApplyPatchTool) Description() string {
-	return "Apply a unified diff patch to the repo using `git apply`. Requires the target repo to be a git repository."
+	return "Apply a unified diff patch to the repo. Uses `git apply` when possible and falls back to a fuzzy applier (tolerates whitespace differences, shifted line numbers and hunks without line numbers)."
 }
+func (t *ApplyPatchTool) Mutating() bool { return true }
 func (t *ApplyPatchTool) InputSchema() any {
 	return map[string]any{
 		"type": "object",
@@ -50,36 +55,154 @@ func (t *ApplyPatchTool) Execute(ctx context.Context, in json.RawMessage) (core.
 		return core.ToolResult{}, errors.New("missing patch")
 	}
 
-	if _, err := os.Stat(filepath.Join(t.repoRoot, ".git")); err != nil {
-		return core.ToolResult{}, errors.New("apply_patch requires a git repository (missing .git)")
+	// Check target paths up front. The git path also checks the paths git
+	// itself reads from the patch, since our parser may reject patches git
+	// accepts (quoted names, binary diffs).
+	var touched []string
+	if files, err := patch.Parse(input.Patch); err == nil {
+		for _, f := range files {
+			for _, rel := range []string{f.OldPath, f.NewPath} {
+				if rel == "" {
+					continue
+				}
+				if _, err := t.guard.resolve(rel); err != nil {
+					return core.ToolResult{}, err
+				}
+				touched = append(touched, rel)
+			}
+		}
 	}
 
+	var gitErr string
+	if _, err := os.Stat(filepath.Join(t.repoRoot, ".git")); err == nil {
+		applied, paths, out, err := t.gitApply(ctx, input.Patch)
+		if err != nil {
+			return core.ToolResult{}, err
+		}
+		if applied {
+			core.ChangeSetFrom(ctx).Add(touched...)
+			core.ChangeSetFrom(ctx).Add(paths...)
+			return core.ToolResult{
+				Content:  "Patch applied.",
+				Metadata: map[string]any{"backend": "git"},
+			}, nil
+		}
+		gitErr = strings.TrimSpace(out)
+	}
+
+	res, err := t.fuzzyApply(input.Patch, gitErr)
+	if err == nil {
+		core.ChangeSetFrom(ctx).Add(touched...)
+	}
+	return res, err
+}
+
+// gitApply checks the paths git reads from the patch against the guard,
+// then runs `git apply --check` and `git apply`. It reports applied=false
+// with git's output when the check fails so the caller can fall back.
+func (t *ApplyPatchTool) gitApply(ctx context.Context, p string) (bool, []string, string, error) {
 	tmpDir, err := os.MkdirTemp("", "rlmkit_patch_*")
 	if err != nil {
-		return core.ToolResult{}, err
+		return false, nil, "", err
 	}
 	defer os.RemoveAll(tmpDir)
 
 	patchPath := filepath.Join(tmpDir, "patch.diff")
-	if err := os.WriteFile(patchPath, []byte(input.Patch), 0o600); err != nil {
-		return core.ToolResult{}, err
+	if err := os.WriteFile(patchPath, []byte(p), 0o600); err != nil {
+		return false, nil, "", err
 	}
 
 	toolCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
+	numstat := exec.CommandContext(toolCtx, "git", "apply", "--numstat", "-z", patchPath)
+	numstat.Dir = t.repoRoot
+	out, err := numstat.Output()
+	if err != nil {
+		// git cannot read the patch; the fuzzy applier checks its own paths.
+		return false, nil, "git apply could not parse the patch", nil
+	}
+	paths := numstatPaths(string(out))
+	for _, rel := range paths {
+		if _, err := t.guard.resolve(rel); err != nil {
+			return false, nil, "", err
+		}
+	}
+
 	// Check first for a clearer error message.
 	check := exec.CommandContext(toolCtx, "git", "apply", "--check", patchPath)
 	check.Dir = t.repoRoot
 	if out, err := check.CombinedOutput(); err != nil {
-		return core.ToolResult{}, errors.New(string(out))
+		return false, nil, string(out), nil
 	}
 
 	apply := exec.CommandContext(toolCtx, "git", "apply", patchPath)
 	apply.Dir = t.repoRoot
 	if out, err := apply.CombinedOutput(); err != nil {
-		return core.ToolResult{}, errors.New(string(out))
+		return false, nil, "", errors.New(string(out))
+	}
+	return true, paths, "", nil
+}
+
+// numstatPaths returns the paths in `git apply --numstat -z` output. Each
+// entry is "added\tdeleted\tpath", or "added\tdeleted\t" followed by the
+// old and new paths of a rename, NUL-separated.
+func numstatPaths(out string) []string {
+	fields := strings.Split(out, "\x00")
+	var paths []string
+	for i := 0; i < len(fields); i++ {
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		if parts[2] != "" {
+			paths = append(paths, parts[2])
+			continue
+		}
+		if i+2 < len(fields) {
+			paths = append(paths, fields[i+1], fields[i+2])
+			i += 2
+		}
+	}
+	return paths
+}
+
+func (t *ApplyPatchTool) fuzzyApply(p string, gitErr string) (core.ToolResult, error) {
+	files, err := patch.Parse(p)
+	if err != nil {
+		if gitErr != "" {
+			return core.ToolResult{}, fmt.Errorf("%s\n(fuzzy fallback: %v)", gitErr, err)
+		}
+		return core.ToolResult{}, err
+	}
+
+	results, err := patch.Apply(files, t.guard.resolve)
+
+	var sb strings.Builder
+	if gitErr != "" {
+		fmt.Fprintf(&sb, "git apply failed:\n%s\n\n", gitErr)
+	}
+	if err != nil {
+		sb.WriteString("Patch not applied (no files were changed):\n")
+	} else {
+		sb.WriteString("Patch applied with fuzzy matching:\n")
+	}
+	for _, r := range results {
+		sb.WriteString(r.String())
+		sb.WriteByte('\n')
+	}
+	if err != nil {
+		if !errors.Is(err, patch.ErrHunksFailed) {
+			sb.WriteString(err.Error())
+		}
+		return core.ToolResult{}, errors.New(strings.TrimRight(sb.String(), "\n"))
 	}
 
-	return core.ToolResult{Content: "Patch applied."}, nil
+	return core.ToolResult{
+		Content: strings.TrimRight(sb.String(), "\n"),
+		Metadata: map[string]any{
+			"backend": "fuzzy",
+			"hunks":   results,
+		},
+	}, nil
 }
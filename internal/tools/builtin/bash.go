@@ -8,27 +8,31 @@ import (
 	"strings"
 	"time"
 
+	"github.com/answerlayer/rlmkit/internal/sandbox"
 	"github.com/answerlayer/rlmkit/internal/tools/core"
 )
 
 type BashTool struct {
-	repoRoot        string
-	enabled         bool
-	allowedPrefixes []string
+	repoRoot  string
+	enabled   bool
+	allowlist commandAllowlist
+	sandbox   sandbox.Config
 }
 
-func NewBashTool(repoRoot string, enabled bool, allowedPrefixes []string) *BashTool {
+func NewBashTool(repoRoot string, enabled bool, allowedPrefixes []string, policy ShellPolicy, sb sandbox.Config) *BashTool {
 	return &BashTool{
-		repoRoot:        repoRoot,
-		enabled:         enabled,
-		allowedPrefixes: allowedPrefixes,
+		repoRoot:  repoRoot,
+		enabled:   enabled,
+		allowlist: commandAllowlist{prefixes: allowedPrefixes, policy: policy},
+		sandbox:   sb,
 	}
 }
 
 func (t *BashTool) Name() string { return "bash" }
 func (t *BashTool) Description() string {
-	return "Run a shell command under the repo (bash -lc). Disabled by default; requires allowlisted script prefixes."
+	return "Run a shell script under the repo (bash -lc). Disabled by default; every command in the script must match an allowlisted prefix."
 }
+func (t *BashTool) Mutating() bool { return true }
 func (t *BashTool) InputSchema() any {
 	return map[string]any{
 		"type": "object",
@@ -67,8 +71,8 @@ func (t *BashTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolRe
 	if input.TimeoutSec <= 0 {
 		input.TimeoutSec = 60
 	}
-	if !t.isAllowed(s) {
-		return core.ToolResult{}, errors.New("script is not in allowlist")
+	if err := t.allowlist.checkScript(s); err != nil {
+		return core.ToolResult{}, err
 	}
 
 	toolCtx, cancel := context.WithTimeout(ctx, time.Duration(input.TimeoutSec)*time.Second)
@@ -76,6 +80,9 @@ func (t *BashTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolRe
 
 	cmd := exec.CommandContext(toolCtx, "/bin/bash", "-lc", s)
 	cmd.Dir = t.repoRoot
+	if err := sandbox.Wrap(cmd, t.sandbox, t.repoRoot); err != nil {
+		return core.ToolResult{}, err
+	}
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return core.ToolResult{}, errors.New(string(out))
@@ -87,16 +94,3 @@ func (t *BashTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolRe
 	}
 	return core.ToolResult{Content: o}, nil
 }
-
-func (t *BashTool) isAllowed(script string) bool {
-	for _, p := range t.allowedPrefixes {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
-		}
-		if strings.HasPrefix(script, p) {
-			return true
-		}
-	}
-	return false
-}
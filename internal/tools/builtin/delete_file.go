@@ -0,0 +1,80 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type DeleteFileTool struct {
+	guard pathGuard
+}
+
+func NewDeleteFileTool(repoRoot string, protected []string) *DeleteFileTool {
+	return &DeleteFileTool{guard: pathGuard{repoRoot: repoRoot, protected: protected}}
+}
+
+func (t *DeleteFileTool) Name() string { return "delete_file" }
+func (t *DeleteFileTool) Description() string {
+	return "Delete a file (or an empty directory) under the repo root."
+}
+func (t *DeleteFileTool) Mutating() bool { return true }
+func (t *DeleteFileTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Path relative to repo root.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type deleteFileInput struct {
+	Path string `json:"path"`
+}
+
+func (t *DeleteFileTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input deleteFileInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Path == "" {
+		return core.ToolResult{}, errors.New("missing path")
+	}
+
+	p, err := t.guard.resolve(input.Path)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	if _, err := os.Lstat(p); err != nil {
+		return core.ToolResult{}, err
+	}
+	if err := t.guard.checkTree(input.Path, p); err != nil {
+		return core.ToolResult{}, err
+	}
+	before := fileSHA256(p)
+
+	select {
+	case <-ctx.Done():
+		return core.ToolResult{}, ctx.Err()
+	default:
+	}
+
+	// os.Remove refuses non-empty directories, which is what we want.
+	if err := os.Remove(p); err != nil {
+		return core.ToolResult{}, err
+	}
+
+	core.ChangeSetFrom(ctx).Add(input.Path)
+	return core.ToolResult{
+		Content:  fmt.Sprintf("Deleted %s.", input.Path),
+		Metadata: hashMetadata(input.Path, before, ""),
+	}, nil
+}
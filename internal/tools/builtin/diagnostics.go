@@ -0,0 +1,328 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+// maxDiagnostics caps the records shown in a diagnostics result.
+const maxDiagnostics = 100
+
+type DiagnosticsTool struct {
+	repoRoot string
+}
+
+func NewDiagnosticsTool(repoRoot string) *DiagnosticsTool {
+	return &DiagnosticsTool{repoRoot: repoRoot}
+}
+
+func (t *DiagnosticsTool) Name() string { return "diagnostics" }
+func (t *DiagnosticsTool) Description() string {
+	return "Check Go code with go build, go vet and gofmt -l. By default checks the packages of files changed this turn (or uncommitted changes). Returns file:line: message records."
+}
+func (t *DiagnosticsTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional files or directories to check instead of this turn's changes.",
+			},
+		},
+	}
+}
+
+type diagnosticsInput struct {
+	Paths []string `json:"paths"`
+}
+
+// diagnostic is a compiler, vet or test message attributed to a source line.
+type diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Col     int    `json:"col,omitempty"`
+	Message string `json:"message"`
+	Source  string `json:"source,omitempty"` // build, vet, gofmt
+}
+
+func (d diagnostic) String() string {
+	switch {
+	case d.File == "":
+		return d.Message
+	case d.Col > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Col, d.Message)
+	case d.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	}
+	return d.File + ": " + d.Message
+}
+
+var goDiagRe = regexp.MustCompile(`^(?:vet: )?(?:\./)?([^\s:]+\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// appendGoDiagnostics parses "file.go:line[:col]: message" lines from go
+// build/vet output, skipping records already in out.
+func appendGoDiagnostics(out []diagnostic, text, source string) []diagnostic {
+	seen := map[string]bool{}
+	for _, d := range out {
+		seen[d.String()] = true
+	}
+	for _, line := range strings.Split(text, "\n") {
+		m := goDiagRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		d := diagnostic{File: filepath.ToSlash(m[1]), Message: m[4], Source: source}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Col, _ = strconv.Atoi(m[3])
+		if seen[d.String()] {
+			continue
+		}
+		seen[d.String()] = true
+		out = append(out, d)
+	}
+	return out
+}
+
+func (t *DiagnosticsTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input diagnosticsInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+
+	scope := "paths"
+	paths := input.Paths
+	for _, p := range paths {
+		if _, err := util.ResolvePathWithinRoot(t.repoRoot, p); err != nil {
+			return core.ToolResult{}, err
+		}
+	}
+	if len(paths) == 0 {
+		scope = "turn"
+		paths = core.ChangeSetFrom(ctx).Paths()
+	}
+	if len(paths) == 0 {
+		scope = "uncommitted"
+		var err error
+		if paths, err = t.uncommittedFiles(ctx); err != nil {
+			return core.ToolResult{}, err
+		}
+	}
+
+	pkgs, files := t.goPackages(paths)
+	if len(pkgs) == 0 {
+		return core.ToolResult{
+			Content:  fmt.Sprintf("diagnostics: no Go packages to check (scope: %s)", scope),
+			Metadata: map[string]any{"ok": true, "scope": scope},
+		}, nil
+	}
+
+	diags, err := t.check(ctx, pkgs, files)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	var sb strings.Builder
+	if len(diags) == 0 {
+		fmt.Fprintf(&sb, "diagnostics: ok (go build, go vet, gofmt on %s)", strings.Join(pkgs, " "))
+	} else {
+		fmt.Fprintf(&sb, "diagnostics: %d issue(s) in %s\n", len(diags), strings.Join(pkgs, " "))
+		for i, d := range diags {
+			if i == maxDiagnostics {
+				fmt.Fprintf(&sb, "...(%d more)\n", len(diags)-maxDiagnostics)
+				break
+			}
+			fmt.Fprintf(&sb, "%s (%s)\n", d, d.Source)
+		}
+	}
+	return core.ToolResult{
+		Content: strings.TrimRight(sb.String(), "\n"),
+		Metadata: map[string]any{
+			"ok":          len(diags) == 0,
+			"scope":       scope,
+			"packages":    pkgs,
+			"diagnostics": diags,
+		},
+	}, nil
+}
+
+// AfterMutation checks the Go files changed so far this turn. It returns ""
+// when the turn has not changed any Go file, so edits to other files stay
+// quiet.
+func (t *DiagnosticsTool) AfterMutation(ctx context.Context) string {
+	hasGo := false
+	for _, p := range core.ChangeSetFrom(ctx).Paths() {
+		if strings.HasSuffix(p, ".go") {
+			hasGo = true
+			break
+		}
+	}
+	if !hasGo {
+		return ""
+	}
+	res, err := t.Execute(ctx, json.RawMessage(`{}`))
+	if err != nil {
+		return "diagnostics failed: " + err.Error()
+	}
+	return res.Content
+}
+
+// check runs go build, then go vet and gofmt -l. go vet repeats build errors,
+// so records already reported by go build are dropped.
+func (t *DiagnosticsTool) check(ctx context.Context, pkgs, files []string) ([]diagnostic, error) {
+	var diags []diagnostic
+	for _, step := range []struct {
+		source string
+		args   []string
+	}{
+		{"build", append([]string{"build", "-o", os.DevNull}, pkgs...)},
+		{"vet", append([]string{"vet"}, pkgs...)},
+	} {
+		out, failed, err := t.run(ctx, "go", step.args...)
+		if err != nil {
+			return nil, err
+		}
+		if !failed {
+			continue
+		}
+		n := len(diags)
+		diags = appendGoDiagnostics(diags, out, step.source)
+		if len(diags) == n && step.source == "build" {
+			// Nothing attributable to a line (e.g. a go.mod problem); report
+			// go's message as is.
+			diags = append(diags, diagnostic{Message: firstLines(out, 10), Source: step.source})
+		}
+	}
+
+	if len(files) > 0 {
+		out, _, err := t.run(ctx, "gofmt", append([]string{"-l"}, files...)...)
+		if err != nil {
+			return nil, err
+		}
+		// Syntax errors are printed as file:line:col: message; other lines
+		// are files that need formatting.
+		diags = appendGoDiagnostics(diags, out, "gofmt")
+		for _, l := range strings.Split(out, "\n") {
+			l = strings.TrimSpace(l)
+			if strings.HasSuffix(l, ".go") && !goDiagRe.MatchString(l) {
+				diags = append(diags, diagnostic{File: filepath.ToSlash(l), Message: "not gofmt-formatted", Source: "gofmt"})
+			}
+		}
+	}
+	return diags, nil
+}
+
+// run returns combined output; failed reports a non-zero exit, while err is
+// reserved for not being able to run the command at all.
+func (t *DiagnosticsTool) run(ctx context.Context, name string, args ...string) (string, bool, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = t.repoRoot
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", false, ctx.Err()
+		}
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) {
+			return "", false, fmt.Errorf("%s: %w", name, err)
+		}
+		return out.String(), true, nil
+	}
+	return out.String(), false, nil
+}
+
+// goPackages maps paths to the "./dir" patterns of the packages containing
+// them and the existing .go files among them. Directories the go tool
+// ignores (testdata, vendor, _ and . prefixes) are skipped.
+func (t *DiagnosticsTool) goPackages(paths []string) (pkgs, files []string) {
+	dirs := map[string]bool{}
+	for _, p := range paths {
+		rel := path.Clean(filepath.ToSlash(p))
+		abs := filepath.Join(t.repoRoot, filepath.FromSlash(rel))
+		dir := rel
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			if !strings.HasSuffix(rel, ".go") {
+				continue
+			}
+			dir = path.Dir(rel)
+			if err == nil {
+				files = append(files, rel)
+			}
+		}
+		if ignoredGoDir(dir) {
+			continue
+		}
+		dirs[dir] = true
+	}
+	for dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(t.repoRoot, filepath.FromSlash(dir), "*.go"))
+		if len(matches) == 0 {
+			continue
+		}
+		if dir == "." {
+			pkgs = append(pkgs, ".")
+		} else {
+			pkgs = append(pkgs, "./"+dir)
+		}
+	}
+	sort.Strings(pkgs)
+	sort.Strings(files)
+	return pkgs, files
+}
+
+func ignoredGoDir(dir string) bool {
+	if dir == "." {
+		return false
+	}
+	for _, seg := range strings.Split(dir, "/") {
+		if seg == "testdata" || seg == "vendor" || strings.HasPrefix(seg, "_") || strings.HasPrefix(seg, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// uncommittedFiles lists modified and untracked files from git status.
+func (t *DiagnosticsTool) uncommittedFiles(ctx context.Context) ([]string, error) {
+	out, err := runGit(ctx, t.repoRoot, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	recs := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(recs); i++ {
+		r := recs[i]
+		if len(r) < 4 {
+			continue
+		}
+		if r[0] == 'R' || r[0] == 'C' {
+			i++ // skip the original path
+		}
+		files = append(files, r[3:])
+	}
+	return files, nil
+}
+
+func firstLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = append(lines[:n], "...")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,148 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+type EditFileTool struct {
+	guard pathGuard
+}
+
+func NewEditFileTool(repoRoot string, protected []string) *EditFileTool {
+	return &EditFileTool{guard: pathGuard{repoRoot: repoRoot, protected: protected}}
+}
+
+func (t *EditFileTool) Name() string { return "edit_file" }
+func (t *EditFileTool) Description() string {
+	return "Edit a file by exact string replacement. old_string must match exactly once unless replace_all is set. Prefer this over apply_patch for small edits."
+}
+func (t *EditFileTool) Mutating() bool { return true }
+func (t *EditFileTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File path relative to repo root.",
+			},
+			"old_string": map[string]any{
+				"type":        "string",
+				"description": "Exact text to replace, including whitespace and indentation. Include enough surrounding lines to make it unique.",
+			},
+			"new_string": map[string]any{
+				"type":        "string",
+				"description": "Replacement text.",
+			},
+			"replace_all": map[string]any{
+				"type":        "boolean",
+				"description": "Replace every occurrence instead of requiring a unique match (default false).",
+			},
+		},
+		"required": []string{"path", "old_string", "new_string"},
+	}
+}
+
+type editFileInput struct {
+	Path       string `json:"path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+}
+
+func (t *EditFileTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input editFileInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Path == "" {
+		return core.ToolResult{}, errors.New("missing path")
+	}
+	if input.OldString == "" {
+		return core.ToolResult{}, errors.New("missing old_string")
+	}
+	if input.OldString == input.NewString {
+		return core.ToolResult{}, errors.New("old_string and new_string are identical")
+	}
+
+	p, err := t.guard.resolve(input.Path)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	if info.IsDir() {
+		return core.ToolResult{}, fmt.Errorf("%s is a directory", input.Path)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	before := string(b)
+
+	oldS, newS := input.OldString, input.NewString
+	n := strings.Count(before, oldS)
+	if n == 0 && strings.Contains(before, "\r\n") && !strings.Contains(oldS, "\r\n") {
+		// Models almost always send LF; retry against CRLF files.
+		oldS = strings.ReplaceAll(oldS, "\n", "\r\n")
+		newS = strings.ReplaceAll(newS, "\n", "\r\n")
+		n = strings.Count(before, oldS)
+	}
+	switch {
+	case n == 0:
+		return core.ToolResult{}, errors.New(notFoundHint(before, input.OldString, input.Path))
+	case n > 1 && !input.ReplaceAll:
+		return core.ToolResult{}, fmt.Errorf("old_string matches %d times in %s; include more surrounding context to make it unique, or set replace_all", n, input.Path)
+	}
+
+	var after string
+	if input.ReplaceAll {
+		after = strings.ReplaceAll(before, oldS, newS)
+	} else {
+		after = strings.Replace(before, oldS, newS, 1)
+	}
+
+	select {
+	case <-ctx.Done():
+		return core.ToolResult{}, ctx.Err()
+	default:
+	}
+
+	if err := os.WriteFile(p, []byte(after), info.Mode().Perm()); err != nil {
+		return core.ToolResult{}, err
+	}
+
+	diff := util.UnifiedDiff("a/"+input.Path, "b/"+input.Path, before, after, 2)
+	core.ChangeSetFrom(ctx).Add(input.Path)
+	meta := hashMetadata(input.Path, fileSHA256String(before), fileSHA256String(after))
+	meta["replacements"] = n
+	return core.ToolResult{
+		Content:  fmt.Sprintf("Edited %s (%d replacement(s)).\n%s", input.Path, n, diff),
+		Metadata: meta,
+	}, nil
+}
+
+// notFoundHint explains a failed match, pointing at a whitespace-insensitive
+// match if one exists so the model can correct its indentation.
+func notFoundHint(content, old, path string) string {
+	msg := fmt.Sprintf("old_string not found in %s", path)
+	first := strings.TrimSpace(strings.SplitN(strings.TrimSpace(old), "\n", 2)[0])
+	if first == "" {
+		return msg
+	}
+	for i, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == first {
+			return fmt.Sprintf("%s (line %d has the same text with different whitespace or the following lines differ; re-read the file and copy old_string exactly)", msg, i+1)
+		}
+	}
+	return msg + " (re-read the file and copy old_string exactly)"
+}
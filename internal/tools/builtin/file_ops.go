@@ -0,0 +1,115 @@
+package builtin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+// pathGuard resolves paths for file-mutating tools: paths must stay under the
+// repo root and must not fall under a protected path (e.g. ".git/", "sessions/").
+type pathGuard struct {
+	repoRoot  string
+	protected []string
+}
+
+func (g pathGuard) resolve(rel string) (string, error) {
+	abs, err := util.ResolvePathWithinRoot(g.repoRoot, rel)
+	if err != nil {
+		return "", err
+	}
+	if err := g.checkProtected(rel, rel); err != nil {
+		return "", err
+	}
+	// Check where the path really leads too: a symlink to .git must not
+	// open a way into it.
+	real, err := util.RealRelPath(g.repoRoot, rel)
+	if err != nil {
+		return "", err
+	}
+	if err := g.checkProtected(rel, real); err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// checkProtected returns an error naming rel if p falls under a protected
+// path.
+func (g pathGuard) checkProtected(rel, p string) error {
+	clean := path.Clean(filepath.ToSlash(p))
+	for _, pp := range g.protected {
+		pp = strings.TrimSuffix(path.Clean(filepath.ToSlash(strings.TrimSpace(pp))), "/")
+		if pp == "" || pp == "." {
+			continue
+		}
+		if clean == pp || strings.HasPrefix(clean, pp+"/") {
+			return fmt.Errorf("%s is protected", rel)
+		}
+		if ok, _ := path.Match(pp, clean); ok {
+			return fmt.Errorf("%s is protected", rel)
+		}
+	}
+	return nil
+}
+
+// checkTree returns an error if rel, resolved to abs, is a directory that
+// contains a protected path, so moving or deleting it would take that along.
+func (g pathGuard) checkTree(rel, abs string) error {
+	info, err := os.Lstat(abs)
+	if err != nil || !info.IsDir() {
+		return nil
+	}
+	clean := path.Clean(filepath.ToSlash(rel))
+	for _, p := range g.protected {
+		p = strings.TrimSuffix(path.Clean(filepath.ToSlash(strings.TrimSpace(p))), "/")
+		if p == "" || p == "." {
+			continue
+		}
+		if clean == "." || strings.HasPrefix(p, clean+"/") {
+			return fmt.Errorf("%s contains protected path %s", rel, p)
+		}
+	}
+	// Patterns can match anywhere below; check what is there.
+	return filepath.WalkDir(abs, func(p string, d os.DirEntry, err error) error {
+		if err != nil || p == abs {
+			return err
+		}
+		sub, err := filepath.Rel(abs, p)
+		if err != nil {
+			return err
+		}
+		inner := path.Join(clean, filepath.ToSlash(sub))
+		if _, err := g.resolve(inner); err != nil {
+			return fmt.Errorf("%s contains protected path %s", rel, inner)
+		}
+		return nil
+	})
+}
+
+// fileSHA256 returns the hex sha256 of the file at p, or "" if it does not exist.
+func fileSHA256(p string) string {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return ""
+	}
+	return fileSHA256String(string(b))
+}
+
+func fileSHA256String(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashMetadata(rel string, before, after string) map[string]any {
+	return map[string]any{
+		"path":          rel,
+		"before_sha256": before,
+		"after_sha256":  after,
+	}
+}
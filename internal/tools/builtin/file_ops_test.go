@@ -0,0 +1,57 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileSymlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, d := range []string{".git/hooks", "src"} {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"gitlink":  ".git",
+		"outlink":  outside,
+		"srclink":  "src",
+		"dangling": filepath.Join(outside, "new.txt"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skip("symlinks unavailable:", err)
+		}
+	}
+
+	tool := NewWriteFileTool(root, []string{".git", "sessions"})
+	cases := []struct {
+		path string
+		ok   bool
+	}{
+		{"gitlink/hooks/pre-commit", false},
+		{"gitlink/config", false},
+		{"outlink/x.txt", false},
+		{"outlink/new/dir/x.txt", false},
+		{"dangling", false},
+		{"srclink/a.txt", true},
+		{"src/new/b.txt", true},
+	}
+	for _, c := range cases {
+		in, _ := json.Marshal(writeFileInput{Path: c.path, Content: "x"})
+		_, err := tool.Execute(context.Background(), in)
+		if (err == nil) != c.ok {
+			t.Errorf("write_file %s: err = %v, want ok=%v", c.path, err, c.ok)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git/hooks/pre-commit")); err == nil {
+		t.Error("wrote into .git/hooks through a symlink")
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("wrote outside the repo: %v", entries)
+	}
+}
@@ -0,0 +1,159 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+type GitBlameTool struct {
+	repoRoot string
+}
+
+func NewGitBlameTool(repoRoot string) *GitBlameTool {
+	return &GitBlameTool{repoRoot: repoRoot}
+}
+
+func (t *GitBlameTool) Name() string { return "git_blame" }
+func (t *GitBlameTool) Description() string {
+	return "Show which commit, author and date last changed each line in a range of a file."
+}
+func (t *GitBlameTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File path relative to repo root.",
+			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "First line (default 1).",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "Last line, inclusive (default start_line+99).",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Optional revision to blame (default: working tree).",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type gitBlameInput struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Ref       string `json:"ref"`
+}
+
+type gitBlameLine struct {
+	Line    int    `json:"line"`
+	Commit  string `json:"commit"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	Summary string `json:"summary"`
+}
+
+func (t *GitBlameTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input gitBlameInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Path == "" {
+		return core.ToolResult{}, errors.New("missing path")
+	}
+	if _, err := util.ResolvePathWithinRoot(t.repoRoot, input.Path); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.StartLine <= 0 {
+		input.StartLine = 1
+	}
+	if input.EndLine <= 0 {
+		input.EndLine = input.StartLine + 99
+	}
+	if input.EndLine < input.StartLine {
+		return core.ToolResult{}, fmt.Errorf("end_line %d is before start_line %d", input.EndLine, input.StartLine)
+	}
+	args := []string{"blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", input.StartLine, input.EndLine)}
+	if input.Ref != "" {
+		if err := checkGitRef(input.Ref); err != nil {
+			return core.ToolResult{}, err
+		}
+		args = append(args, input.Ref)
+	}
+	args = append(args, "--", input.Path)
+	out, err := runGit(ctx, t.repoRoot, args...)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	lines, texts := parseBlamePorcelain(out)
+	var sb strings.Builder
+	for i, l := range lines {
+		fmt.Fprintf(&sb, "%.8s %s %-16.16s %5d| %s\n", l.Commit, l.Date, l.Author, l.Line, texts[i])
+	}
+	content, _ := truncateGitOutput(sb.String(), gitOutputLimit)
+	return core.ToolResult{
+		Content:  content,
+		Metadata: map[string]any{"path": input.Path, "lines": lines},
+	}, nil
+}
+
+// parseBlamePorcelain parses `git blame --porcelain`. Commit details are only
+// printed the first time a commit appears, so they are remembered by hash.
+func parseBlamePorcelain(out string) ([]gitBlameLine, []string) {
+	type commitInfo struct{ author, date, summary string }
+	commits := map[string]*commitInfo{}
+	var lines []gitBlameLine
+	var texts []string
+	var cur *gitBlameLine
+	for _, l := range strings.Split(out, "\n") {
+		if strings.HasPrefix(l, "\t") {
+			if cur != nil {
+				if ci := commits[cur.Commit]; ci != nil {
+					cur.Author, cur.Date, cur.Summary = ci.author, ci.date, ci.summary
+				}
+				lines = append(lines, *cur)
+				texts = append(texts, l[1:])
+				cur = nil
+			}
+			continue
+		}
+		f := strings.Fields(l)
+		if cur == nil {
+			// Header: <hash> <orig line> <final line> [<group size>]
+			if len(f) >= 3 && len(f[0]) == 40 {
+				n, _ := strconv.Atoi(f[2])
+				cur = &gitBlameLine{Commit: f[0], Line: n}
+				if commits[f[0]] == nil {
+					commits[f[0]] = &commitInfo{}
+				}
+			}
+			continue
+		}
+		ci := commits[cur.Commit]
+		key, val, _ := strings.Cut(l, " ")
+		switch key {
+		case "author":
+			ci.author = val
+		case "author-time":
+			if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+				ci.date = time.Unix(sec, 0).UTC().Format("2006-01-02")
+			}
+		case "summary":
+			ci.summary = val
+		}
+	}
+	return lines, texts
+}
@@ -0,0 +1,152 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type GitCommitTool struct {
+	repoRoot string
+	guard    pathGuard
+	enabled  bool
+	runHooks bool
+}
+
+// NewGitCommitTool returns the git_commit tool. Hooks are skipped
+// (--no-verify) unless runHooks is set: they run outside any sandbox, and
+// the model may have written them.
+func NewGitCommitTool(repoRoot string, protected []string, enabled bool, runHooks bool) *GitCommitTool {
+	return &GitCommitTool{repoRoot: repoRoot, guard: pathGuard{repoRoot: repoRoot, protected: protected}, enabled: enabled, runHooks: runHooks}
+}
+
+func (t *GitCommitTool) Name() string { return "git_commit" }
+func (t *GitCommitTool) Description() string {
+	return "Commit the currently staged changes. Disabled by default. A message is generated from the staged files when none is given."
+}
+func (t *GitCommitTool) Mutating() bool { return true }
+func (t *GitCommitTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":        "string",
+				"description": "Commit message (optional; generated from the staged files if empty).",
+			},
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional paths to stage (git add) before committing.",
+			},
+		},
+	}
+}
+
+type gitCommitInput struct {
+	Message string   `json:"message"`
+	Paths   []string `json:"paths"`
+}
+
+func (t *GitCommitTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	if !t.enabled {
+		return core.ToolResult{}, errors.New("git_commit is disabled (enable explicitly in config)")
+	}
+	var input gitCommitInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if len(input.Paths) > 0 {
+		for _, p := range input.Paths {
+			if _, err := t.guard.resolve(p); err != nil {
+				return core.ToolResult{}, err
+			}
+		}
+		// Literal pathspecs: magic such as ":/" or ":(top)sessions" would
+		// stage paths the guard never saw.
+		if _, err := runGit(ctx, t.repoRoot, append([]string{"--literal-pathspecs", "add", "--"}, input.Paths...)...); err != nil {
+			return core.ToolResult{}, err
+		}
+	}
+
+	numOut, err := runGit(ctx, t.repoRoot, "diff", "--cached", "--numstat")
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	files := parseNumstat(numOut)
+	if len(files) == 0 {
+		return core.ToolResult{}, errors.New("nothing staged to commit")
+	}
+	msg := strings.TrimSpace(input.Message)
+	if msg == "" {
+		msg = generateCommitMessage(files)
+	}
+
+	args := []string{"commit", "-q", "-m", msg}
+	if !t.runHooks {
+		args = append(args, "--no-verify")
+	}
+	if _, err := runGit(ctx, t.repoRoot, args...); err != nil {
+		return core.ToolResult{}, err
+	}
+	hash, err := runGit(ctx, t.repoRoot, "rev-parse", "HEAD")
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	hash = strings.TrimSpace(hash)
+	return core.ToolResult{
+		Content:  fmt.Sprintf("Committed %.12s (%d file(s)): %s", hash, len(files), strings.SplitN(msg, "\n", 2)[0]),
+		Metadata: map[string]any{"commit": hash, "message": msg, "files": files},
+	}, nil
+}
+
+// generateCommitMessage summarizes staged files, e.g. "Update engine.go and
+// prompt.go" or "Update 7 files in internal/tools", listing files in the body.
+func generateCommitMessage(files []gitNumstat) string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Path
+	}
+	var subject string
+	switch len(files) {
+	case 1:
+		subject = "Update " + names[0]
+	case 2, 3:
+		bases := make([]string, len(names))
+		for i, n := range names {
+			bases[i] = path.Base(n)
+		}
+		subject = "Update " + strings.Join(bases[:len(bases)-1], ", ") + " and " + bases[len(bases)-1]
+	default:
+		subject = fmt.Sprintf("Update %d files", len(files))
+		if dir := commonDir(names); dir != "" {
+			subject += " in " + dir
+		}
+	}
+	var body strings.Builder
+	for _, f := range files {
+		if f.Added < 0 {
+			fmt.Fprintf(&body, "- %s (binary)\n", f.Path)
+		} else {
+			fmt.Fprintf(&body, "- %s (+%d -%d)\n", f.Path, f.Added, f.Deleted)
+		}
+	}
+	return subject + "\n\n" + body.String()
+}
+
+func commonDir(paths []string) string {
+	prefix := path.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for prefix != "." && prefix != p && !strings.HasPrefix(p, prefix+"/") {
+			prefix = path.Dir(prefix)
+		}
+	}
+	if prefix == "." {
+		return ""
+	}
+	return prefix
+}
@@ -0,0 +1,52 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitOutputLimit caps the text returned by the git tools.
+const gitOutputLimit = 50_000
+
+// runGit runs git in repoRoot with a timeout and returns stdout. Errors carry
+// git's stderr so the model sees why a command failed.
+func runGit(ctx context.Context, repoRoot string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--no-pager", "-c", "color.ui=never"}, args...)...)
+	cmd.Dir = repoRoot
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// checkGitRef rejects refs that git would parse as options.
+func checkGitRef(ref string) error {
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid ref %q", ref)
+	}
+	if strings.ContainsAny(ref, " \t\n") {
+		return errors.New("ref must not contain whitespace")
+	}
+	return nil
+}
+
+func truncateGitOutput(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	return s[:max] + "\n...(truncated)", true
+}
@@ -0,0 +1,135 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+type GitDiffTool struct {
+	repoRoot string
+}
+
+func NewGitDiffTool(repoRoot string) *GitDiffTool {
+	return &GitDiffTool{repoRoot: repoRoot}
+}
+
+func (t *GitDiffTool) Name() string { return "git_diff" }
+func (t *GitDiffTool) Description() string {
+	return "Show a git diff of the working tree (or staged changes, or against a ref), optionally limited to a path."
+}
+func (t *GitDiffTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional file or directory to limit the diff to.",
+			},
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "Show staged changes (git diff --cached) instead of unstaged ones.",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Optional commit or range to diff against (e.g. HEAD~3, main...HEAD).",
+			},
+			"stat": map[string]any{
+				"type":        "boolean",
+				"description": "Only show per-file line counts.",
+			},
+			"context": map[string]any{
+				"type":        "integer",
+				"description": "Lines of context (default 3).",
+			},
+		},
+	}
+}
+
+type gitDiffInput struct {
+	Path    string `json:"path"`
+	Staged  bool   `json:"staged"`
+	Ref     string `json:"ref"`
+	Stat    bool   `json:"stat"`
+	Context *int   `json:"context"`
+}
+
+type gitNumstat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`   // -1 for binary files
+	Deleted int    `json:"deleted"` // -1 for binary files
+}
+
+func (t *GitDiffTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input gitDiffInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	args := []string{"diff", "--no-ext-diff"}
+	if input.Staged {
+		args = append(args, "--cached")
+	}
+	if input.Context != nil && *input.Context >= 0 {
+		args = append(args, "-U"+strconv.Itoa(*input.Context))
+	}
+	if input.Ref != "" {
+		if err := checkGitRef(input.Ref); err != nil {
+			return core.ToolResult{}, err
+		}
+		args = append(args, input.Ref)
+	}
+	var pathspec []string
+	if input.Path != "" {
+		if _, err := util.ResolvePathWithinRoot(t.repoRoot, input.Path); err != nil {
+			return core.ToolResult{}, err
+		}
+		pathspec = []string{"--", input.Path}
+	}
+
+	numOut, err := runGit(ctx, t.repoRoot, append(append(append([]string{}, args...), "--numstat"), pathspec...)...)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	files := parseNumstat(numOut)
+
+	var content string
+	if input.Stat {
+		content, err = runGit(ctx, t.repoRoot, append(append(append([]string{}, args...), "--stat"), pathspec...)...)
+	} else {
+		content, err = runGit(ctx, t.repoRoot, append(args, pathspec...)...)
+	}
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	if content == "" {
+		content = "(no changes)"
+	}
+	content, truncated := truncateGitOutput(content, gitOutputLimit)
+	return core.ToolResult{
+		Content:  content,
+		Metadata: map[string]any{"files": files, "truncated": truncated},
+	}, nil
+}
+
+func parseNumstat(out string) []gitNumstat {
+	var files []gitNumstat
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		f := strings.SplitN(line, "\t", 3)
+		if len(f) != 3 {
+			continue
+		}
+		s := gitNumstat{Path: f[2], Added: -1, Deleted: -1}
+		if n, err := strconv.Atoi(f[0]); err == nil {
+			s.Added = n
+		}
+		if n, err := strconv.Atoi(f[1]); err == nil {
+			s.Deleted = n
+		}
+		files = append(files, s)
+	}
+	return files
+}
@@ -0,0 +1,107 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+type GitLogTool struct {
+	repoRoot string
+}
+
+func NewGitLogTool(repoRoot string) *GitLogTool {
+	return &GitLogTool{repoRoot: repoRoot}
+}
+
+func (t *GitLogTool) Name() string { return "git_log" }
+func (t *GitLogTool) Description() string {
+	return "List recent git commits (hash, date, author, subject), optionally only those touching a path."
+}
+func (t *GitLogTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional file or directory; only commits touching it are listed.",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Optional starting ref or range (default HEAD).",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum commits (default 20, max 200).",
+			},
+		},
+	}
+}
+
+type gitLogInput struct {
+	Path  string `json:"path"`
+	Ref   string `json:"ref"`
+	Limit int    `json:"limit"`
+}
+
+type gitCommitInfo struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+func (t *GitLogTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input gitLogInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Limit <= 0 {
+		input.Limit = 20
+	}
+	if input.Limit > 200 {
+		input.Limit = 200
+	}
+	args := []string{"log", "--no-decorate", "-n", strconv.Itoa(input.Limit), "--format=%H%x1f%an%x1f%ad%x1f%s%x1e", "--date=short"}
+	if input.Ref != "" {
+		if err := checkGitRef(input.Ref); err != nil {
+			return core.ToolResult{}, err
+		}
+		args = append(args, input.Ref)
+	}
+	if input.Path != "" {
+		if _, err := util.ResolvePathWithinRoot(t.repoRoot, input.Path); err != nil {
+			return core.ToolResult{}, err
+		}
+		args = append(args, "--", input.Path)
+	}
+	out, err := runGit(ctx, t.repoRoot, args...)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	var commits []gitCommitInfo
+	var sb strings.Builder
+	for _, rec := range strings.Split(out, "\x1e") {
+		f := strings.Split(strings.TrimSpace(rec), "\x1f")
+		if len(f) != 4 {
+			continue
+		}
+		c := gitCommitInfo{Hash: f[0], Author: f[1], Date: f[2], Subject: f[3]}
+		commits = append(commits, c)
+		fmt.Fprintf(&sb, "%.12s %s %s: %s\n", c.Hash, c.Date, c.Author, c.Subject)
+	}
+	if len(commits) == 0 {
+		sb.WriteString("(no commits)\n")
+	}
+	content, _ := truncateGitOutput(sb.String(), gitOutputLimit)
+	return core.ToolResult{
+		Content:  content,
+		Metadata: map[string]any{"commits": commits},
+	}, nil
+}
@@ -0,0 +1,80 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+type GitShowTool struct {
+	repoRoot string
+}
+
+func NewGitShowTool(repoRoot string) *GitShowTool {
+	return &GitShowTool{repoRoot: repoRoot}
+}
+
+func (t *GitShowTool) Name() string { return "git_show" }
+func (t *GitShowTool) Description() string {
+	return "Show a git commit: message, author, date and diff (optionally limited to a path, or only the file stats)."
+}
+func (t *GitShowTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"commit": map[string]any{
+				"type":        "string",
+				"description": "Commit hash or ref (default HEAD).",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional path to limit the diff to.",
+			},
+			"stat": map[string]any{
+				"type":        "boolean",
+				"description": "Only show per-file line counts instead of the full diff.",
+			},
+		},
+	}
+}
+
+type gitShowInput struct {
+	Commit string `json:"commit"`
+	Path   string `json:"path"`
+	Stat   bool   `json:"stat"`
+}
+
+func (t *GitShowTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input gitShowInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Commit == "" {
+		input.Commit = "HEAD"
+	}
+	if err := checkGitRef(input.Commit); err != nil {
+		return core.ToolResult{}, err
+	}
+	args := []string{"show", "--no-ext-diff", "--format=fuller"}
+	if input.Stat {
+		args = append(args, "--stat")
+	}
+	args = append(args, input.Commit)
+	if input.Path != "" {
+		if _, err := util.ResolvePathWithinRoot(t.repoRoot, input.Path); err != nil {
+			return core.ToolResult{}, err
+		}
+		args = append(args, "--", input.Path)
+	}
+	out, err := runGit(ctx, t.repoRoot, args...)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	content, truncated := truncateGitOutput(out, gitOutputLimit)
+	return core.ToolResult{
+		Content:  content,
+		Metadata: map[string]any{"commit": input.Commit, "truncated": truncated},
+	}, nil
+}
@@ -0,0 +1,126 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type GitStatusTool struct {
+	repoRoot string
+}
+
+func NewGitStatusTool(repoRoot string) *GitStatusTool {
+	return &GitStatusTool{repoRoot: repoRoot}
+}
+
+func (t *GitStatusTool) Name() string { return "git_status" }
+func (t *GitStatusTool) Description() string {
+	return "Show the git branch and which files are staged, modified or untracked."
+}
+func (t *GitStatusTool) InputSchema() any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+type gitStatusEntry struct {
+	Path     string `json:"path"`
+	OrigPath string `json:"orig_path,omitempty"`
+	Index    string `json:"index"`
+	Worktree string `json:"worktree"`
+}
+
+func (t *GitStatusTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	out, err := runGit(ctx, t.repoRoot, "status", "--porcelain=v1", "--branch", "-z")
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	branch := ""
+	var entries []gitStatusEntry
+	recs := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(recs); i++ {
+		r := recs[i]
+		if strings.HasPrefix(r, "## ") {
+			branch = r[3:]
+			continue
+		}
+		if len(r) < 4 {
+			continue
+		}
+		e := gitStatusEntry{Index: r[:1], Worktree: r[1:2], Path: r[3:]}
+		// Renames and copies are followed by the original path.
+		if (e.Index == "R" || e.Index == "C") && i+1 < len(recs) {
+			i++
+			e.OrigPath = recs[i]
+		}
+		entries = append(entries, e)
+	}
+
+	var staged, unstaged, untracked, conflicted []string
+	for _, e := range entries {
+		name := e.Path
+		if e.OrigPath != "" {
+			name = e.OrigPath + " -> " + e.Path
+		}
+		switch {
+		case e.Index == "?":
+			untracked = append(untracked, e.Path)
+		case e.Index == "U" || e.Worktree == "U" || (e.Index == "A" && e.Worktree == "A") || (e.Index == "D" && e.Worktree == "D"):
+			conflicted = append(conflicted, e.Path)
+		default:
+			if e.Index != " " {
+				staged = append(staged, gitStatusWord(e.Index)+" "+name)
+			}
+			if e.Worktree != " " {
+				unstaged = append(unstaged, gitStatusWord(e.Worktree)+" "+e.Path)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "branch: %s\n", branch)
+	if len(entries) == 0 {
+		sb.WriteString("working tree clean\n")
+	}
+	for _, g := range []struct {
+		title string
+		items []string
+	}{{"staged", staged}, {"unstaged", unstaged}, {"untracked", untracked}, {"conflicts", conflicted}} {
+		if len(g.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s (%d):\n", g.title, len(g.items))
+		for _, it := range g.items {
+			fmt.Fprintf(&sb, "  %s\n", it)
+		}
+	}
+	content, _ := truncateGitOutput(sb.String(), gitOutputLimit)
+	return core.ToolResult{
+		Content:  content,
+		Metadata: map[string]any{"branch": branch, "files": entries},
+	}, nil
+}
+
+func gitStatusWord(code string) string {
+	switch code {
+	case "M":
+		return "modified:"
+	case "A":
+		return "added:"
+	case "D":
+		return "deleted:"
+	case "R":
+		return "renamed:"
+	case "C":
+		return "copied:"
+	case "T":
+		return "typechange:"
+	}
+	return code + ":"
+}
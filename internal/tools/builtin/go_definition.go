@@ -0,0 +1,107 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type GoDefinitionTool struct {
+	index *gosym.Cache
+}
+
+func NewGoDefinitionTool(index *gosym.Cache) *GoDefinitionTool {
+	return &GoDefinitionTool{index: index}
+}
+
+func (t *GoDefinitionTool) Name() string { return "go_definition" }
+func (t *GoDefinitionTool) Description() string {
+	return "Find where a Go symbol is declared and show its signature (or type definition), line range and doc comment."
+}
+func (t *GoDefinitionTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"symbol": map[string]any{
+				"type":        "string",
+				"description": "Symbol to find: Name, Type.Method, pkg.Name or pkg.Type.Member (pkg may be a package name or path like internal/agent).",
+			},
+		},
+		"required": []string{"symbol"},
+	}
+}
+
+type goSymbolInput struct {
+	Symbol string `json:"symbol"`
+	Max    int    `json:"max"`
+}
+
+func (t *GoDefinitionTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input goSymbolInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Symbol == "" {
+		return core.ToolResult{}, errors.New("missing symbol")
+	}
+	idx, err := t.index.Index(ctx)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	decls := idx.Lookup(input.Symbol)
+	if len(decls) == 0 {
+		return core.ToolResult{}, fmt.Errorf("no declaration found for %q", input.Symbol)
+	}
+
+	const maxShown = 10
+	var sb strings.Builder
+	var locs []string
+	for i, d := range decls {
+		if i == maxShown {
+			fmt.Fprintf(&sb, "...(%d more matches; qualify the symbol with its package or type)\n", len(decls)-maxShown)
+			break
+		}
+		start, end := idx.Lines(d)
+		loc := fmt.Sprintf("%s:%d-%d", idx.Location(d.Pos).File, start, end)
+		locs = append(locs, loc)
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "%s %s (%s) %s\n", d.Kind, d.QualifiedName(), d.Pkg.Path, loc)
+		sb.WriteString(idx.Signature(d, 40))
+		sb.WriteByte('\n')
+		if doc := strings.TrimSpace(d.Doc); doc != "" {
+			sb.WriteString("\n" + doc + "\n")
+		}
+	}
+	return core.ToolResult{
+		Content:  sb.String(),
+		Metadata: map[string]any{"matches": len(decls), "locations": locs},
+	}, nil
+}
+
+// lookupUnique resolves symbol to exactly one declaration, listing the
+// candidates when it is ambiguous.
+func lookupUnique(idx *gosym.Index, symbol string) (*gosym.Decl, error) {
+	decls := idx.Lookup(symbol)
+	switch len(decls) {
+	case 0:
+		return nil, fmt.Errorf("no declaration found for %q", symbol)
+	case 1:
+		return decls[0], nil
+	}
+	var names []string
+	for i, d := range decls {
+		if i == 10 {
+			names = append(names, "...")
+			break
+		}
+		names = append(names, fmt.Sprintf("%s (%s, %s)", d.QualifiedName(), d.Kind, idx.Location(d.Pos).File))
+	}
+	return nil, fmt.Errorf("%q is ambiguous; qualify it as one of: %s", symbol, strings.Join(names, "; "))
+}
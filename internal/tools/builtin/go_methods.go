@@ -0,0 +1,99 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type GoMethodsTool struct {
+	index *gosym.Cache
+}
+
+func NewGoMethodsTool(index *gosym.Cache) *GoMethodsTool {
+	return &GoMethodsTool{index: index}
+}
+
+func (t *GoMethodsTool) Name() string { return "go_methods" }
+func (t *GoMethodsTool) Description() string {
+	return "Show the method set of a Go type declared in this module, with signatures, locations and promoted methods."
+}
+func (t *GoMethodsTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type": map[string]any{
+				"type":        "string",
+				"description": "Type name, optionally qualified (e.g. Engine, agent.Engine, internal/agent.Engine).",
+			},
+		},
+		"required": []string{"type"},
+	}
+}
+
+type goMethodsInput struct {
+	Type string `json:"type"`
+}
+
+func (t *GoMethodsTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input goMethodsInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Type == "" {
+		return core.ToolResult{}, errors.New("missing type")
+	}
+	idx, err := t.index.Index(ctx)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	d, err := lookupUnique(idx, input.Type)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	methods, err := idx.Methods(d)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "type %s (%s): %d method(s)\n", d.QualifiedName(), idx.Location(d.Pos).File, len(methods))
+	for _, m := range methods {
+		sb.WriteString(m.Sig)
+		var notes []string
+		if m.Decl != nil {
+			start, end := idx.Lines(m.Decl)
+			notes = append(notes, fmt.Sprintf("%s:%d-%d", idx.Location(m.Decl.Pos).File, start, end))
+		}
+		if m.Promoted != "" {
+			notes = append(notes, "promoted from "+m.Promoted)
+		}
+		if m.Pointer {
+			notes = append(notes, "pointer receiver")
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&sb, "  // %s", strings.Join(notes, ", "))
+		}
+		sb.WriteByte('\n')
+		if m.Decl != nil && m.Decl.Doc != "" {
+			fmt.Fprintf(&sb, "    %s\n", firstSentence(m.Decl.Doc))
+		}
+	}
+	return core.ToolResult{
+		Content:  sb.String(),
+		Metadata: map[string]any{"type": d.QualifiedName(), "count": len(methods)},
+	}, nil
+}
+
+func firstSentence(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i >= 0 {
+		return doc[:i+1]
+	}
+	return doc
+}
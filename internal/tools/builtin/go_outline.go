@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+type GoOutlineTool struct {
+	repoRoot string
+	index    *gosym.Cache
+}
+
+func NewGoOutlineTool(repoRoot string, index *gosym.Cache) *GoOutlineTool {
+	return &GoOutlineTool{repoRoot: repoRoot, index: index}
+}
+
+func (t *GoOutlineTool) Name() string { return "go_outline" }
+func (t *GoOutlineTool) Description() string {
+	return "Outline a Go file: its types, functions, methods, vars and consts with line ranges, so you can read_file just the part you need."
+}
+func (t *GoOutlineTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Go file path relative to repo root.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type goOutlineInput struct {
+	Path string `json:"path"`
+}
+
+func (t *GoOutlineTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input goOutlineInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Path == "" {
+		return core.ToolResult{}, errors.New("missing path")
+	}
+	if _, err := util.ResolvePathWithinRoot(t.repoRoot, input.Path); err != nil {
+		return core.ToolResult{}, err
+	}
+	idx, err := t.index.Index(ctx)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	pkgName, entries, err := idx.Outline(input.Path)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (package %s)\n", input.Path, pkgName)
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "%5d-%-5d %s\n", e.StartLine, e.EndLine, e.Summary)
+	}
+	return core.ToolResult{
+		Content:  sb.String(),
+		Metadata: map[string]any{"path": input.Path, "declarations": len(entries)},
+	}, nil
+}
@@ -0,0 +1,69 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type GoPackagesTool struct {
+	index *gosym.Cache
+}
+
+func NewGoPackagesTool(index *gosym.Cache) *GoPackagesTool {
+	return &GoPackagesTool{index: index}
+}
+
+func (t *GoPackagesTool) Name() string { return "go_packages" }
+func (t *GoPackagesTool) Description() string {
+	return "List the Go packages in this module with their directories, file counts and doc synopsis."
+}
+func (t *GoPackagesTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"filter": map[string]any{
+				"type":        "string",
+				"description": "Optional substring to filter import paths.",
+			},
+		},
+	}
+}
+
+type goPackagesInput struct {
+	Filter string `json:"filter"`
+}
+
+func (t *GoPackagesTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input goPackagesInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	idx, err := t.index.Index(ctx)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "module %s\n", idx.Module)
+	n := 0
+	for _, p := range idx.Packages {
+		if input.Filter != "" && !strings.Contains(p.Path, input.Filter) {
+			continue
+		}
+		n++
+		fmt.Fprintf(&sb, "%s (package %s, dir %s, %d files)", p.Path, p.Name, p.Dir, len(p.Files))
+		if p.Doc != "" {
+			fmt.Fprintf(&sb, " - %s", p.Doc)
+		}
+		sb.WriteByte('\n')
+	}
+	return core.ToolResult{
+		Content:  sb.String(),
+		Metadata: map[string]any{"module": idx.Module, "count": n},
+	}, nil
+}
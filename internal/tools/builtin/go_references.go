@@ -0,0 +1,77 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type GoReferencesTool struct {
+	index *gosym.Cache
+}
+
+func NewGoReferencesTool(index *gosym.Cache) *GoReferencesTool {
+	return &GoReferencesTool{index: index}
+}
+
+func (t *GoReferencesTool) Name() string { return "go_references" }
+func (t *GoReferencesTool) Description() string {
+	return "List every place a Go symbol declared in this module is used (type-checked, not text search)."
+}
+func (t *GoReferencesTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"symbol": map[string]any{
+				"type":        "string",
+				"description": "Symbol: Name, Type.Method, pkg.Name or pkg.Type.Member. Must resolve to a single declaration.",
+			},
+			"max": map[string]any{
+				"type":        "integer",
+				"description": "Maximum references to return (default 100).",
+			},
+		},
+		"required": []string{"symbol"},
+	}
+}
+
+func (t *GoReferencesTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input goSymbolInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Symbol == "" {
+		return core.ToolResult{}, errors.New("missing symbol")
+	}
+	if input.Max <= 0 {
+		input.Max = 100
+	}
+	idx, err := t.index.Index(ctx)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	d, err := lookupUnique(idx, input.Symbol)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	refs := idx.References(d)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s declared at %s: %d reference(s)\n", d.Kind, d.QualifiedName(), idx.Location(d.Pos), len(refs))
+	for i, r := range refs {
+		if i == input.Max {
+			fmt.Fprintf(&sb, "...(truncated; %d more)\n", len(refs)-input.Max)
+			break
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", r, idx.LineText(r))
+	}
+	return core.ToolResult{
+		Content:  sb.String(),
+		Metadata: map[string]any{"symbol": d.QualifiedName(), "count": len(refs)},
+	}, nil
+}
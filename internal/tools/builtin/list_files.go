@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
-	"path/filepath"
+	"path"
+	"sort"
 	"strings"
 
+	"github.com/answerlayer/rlmkit/internal/ignore"
 	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
 )
 
 type ListFilesTool struct {
@@ -21,118 +24,243 @@ func NewListFilesTool(repoRoot string) *ListFilesTool {
 
 func (t *ListFilesTool) Name() string { return "list_files" }
 func (t *ListFilesTool) Description() string {
-	return "List files under the repo root. Useful for discovering project structure."
+	return "List files under the repo root, skipping .gitignore/.rlmkitignore matches. Use format=tree for a depth-limited overview with sizes."
 }
 func (t *ListFilesTool) InputSchema() any {
 	return map[string]any{
 		"type": "object",
 		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional directory to list, relative to repo root (default: repo root).",
+			},
 			"glob": map[string]any{
 				"type":        "string",
-				"description": "Optional glob pattern relative to repo root (e.g. \"**/*.go\").",
+				"description": "Optional glob relative to repo root (e.g. \"**/*.go\", \"internal/**/*_test.go\"). A pattern without \"/\" matches file names at any depth.",
+			},
+			"format": map[string]any{
+				"type":        "string",
+				"enum":        []string{"list", "tree"},
+				"description": "\"list\" (default) returns JSON paths; \"tree\" returns an indented tree with sizes.",
+			},
+			"max_depth": map[string]any{
+				"type":        "integer",
+				"description": "Tree format only: deepest level to expand; deeper directories are summarized (default 3).",
 			},
 			"max": map[string]any{
 				"type":        "integer",
-				"description": "Maximum number of paths to return (default 2000).",
+				"description": "Maximum number of paths (list) or lines (tree) to return (default 2000).",
 			},
 		},
 	}
 }
 
 type listFilesInput struct {
-	Glob string `json:"glob"`
-	Max  int    `json:"max"`
+	Path     string `json:"path"`
+	Glob     string `json:"glob"`
+	Format   string `json:"format"`
+	MaxDepth int    `json:"max_depth"`
+	Max      int    `json:"max"`
 }
 
 func (t *ListFilesTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
 	var input listFilesInput
-	_ = json.Unmarshal(in, &input)
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
 	if input.Max <= 0 {
 		input.Max = 2000
 	}
+	if input.MaxDepth <= 0 {
+		input.MaxDepth = 3
+	}
+	base := ""
+	if input.Path != "" && input.Path != "." {
+		if _, err := util.ResolvePathWithinRoot(t.repoRoot, input.Path); err != nil {
+			return core.ToolResult{}, err
+		}
+		base = path.Clean(strings.TrimSuffix(input.Path, "/"))
+	}
+
+	switch input.Format {
+	case "", "list":
+		return t.list(ctx, base, input)
+	case "tree":
+		return t.tree(ctx, base, input)
+	default:
+		return core.ToolResult{}, fmt.Errorf("unknown format %q (want list or tree)", input.Format)
+	}
+}
 
+func (t *ListFilesTool) list(ctx context.Context, base string, input listFilesInput) (core.ToolResult, error) {
 	var out []string
-	err := filepath.WalkDir(t.repoRoot, func(path string, d fs.DirEntry, err error) error {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-		if err != nil {
+	total := 0
+	omitted := map[string]int{}
+	err := ignore.New(t.repoRoot).Walk(ctx, base, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() || !matchPathGlob(input.Glob, rel) {
 			return nil
 		}
-
-		rel, rerr := filepath.Rel(t.repoRoot, path)
-		if rerr != nil {
-			return nil
+		total++
+		if len(out) < input.Max {
+			out = append(out, rel)
+		} else {
+			omitted[summaryDir(base, rel)]++
 		}
-		if rel == "." {
-			return nil
+		return nil
+	})
+	if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
+		return core.ToolResult{}, err
+	}
+
+	res := map[string]any{
+		"count": len(out),
+		"paths": out,
+	}
+	if total > len(out) {
+		res["truncated"] = true
+		res["total"] = total
+		res["omitted_by_dir"] = omitted
+	}
+	b, _ := json.MarshalIndent(res, "", "  ")
+	return core.ToolResult{Content: string(b)}, nil
+}
+
+// summaryDir returns the directory (at most two levels below base) used to
+// summarize paths that did not fit in the result.
+func summaryDir(base, rel string) string {
+	sub := rel
+	if base != "" {
+		sub = strings.TrimPrefix(rel, base+"/")
+	}
+	segs := strings.Split(sub, "/")
+	if len(segs) == 1 {
+		if base == "" {
+			return "./"
 		}
-		rel = filepath.ToSlash(rel)
+		return base + "/"
+	}
+	if len(segs) > 3 {
+		segs = segs[:2]
+	} else {
+		segs = segs[:len(segs)-1]
+	}
+	dir := strings.Join(segs, "/")
+	if base != "" {
+		dir = base + "/" + dir
+	}
+	return dir + "/"
+}
 
-		if d.IsDir() {
-			// Avoid huge/irrelevant directories by default.
-			if strings.HasPrefix(rel, ".git/") || rel == ".git" {
-				return fs.SkipDir
-			}
+type treeNode struct {
+	name     string
+	dir      bool
+	size     int64 // file size, or total size of matching files below a dir
+	files    int   // matching files below a dir
+	children map[string]*treeNode
+}
+
+func (t *ListFilesTool) tree(ctx context.Context, base string, input listFilesInput) (core.ToolResult, error) {
+	root := &treeNode{dir: true, children: map[string]*treeNode{}}
+	err := ignore.New(t.repoRoot).Walk(ctx, base, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() || !matchPathGlob(input.Glob, rel) {
 			return nil
 		}
-
-		if input.Glob != "" {
-			ok, _ := doublestarMatch(input.Glob, rel)
+		var size int64
+		if info, err := d.Info(); err == nil {
+			size = info.Size()
+		}
+		sub := rel
+		if base != "" {
+			sub = strings.TrimPrefix(rel, base+"/")
+		}
+		n := root
+		segs := strings.Split(sub, "/")
+		for i, seg := range segs {
+			n.files++
+			n.size += size
+			c, ok := n.children[seg]
 			if !ok {
-				return nil
+				c = &treeNode{name: seg, dir: i < len(segs)-1}
+				if c.dir {
+					c.children = map[string]*treeNode{}
+				}
+				n.children[seg] = c
 			}
+			n = c
 		}
-
-		out = append(out, rel)
-		if len(out) >= input.Max {
-			return errStopWalk
-		}
+		n.files, n.size = 1, size
 		return nil
 	})
-	if err != nil && err != errStopWalk && err != context.Canceled && err != context.DeadlineExceeded {
+	if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
 		return core.ToolResult{}, err
 	}
 
-	b, _ := json.MarshalIndent(map[string]any{
-		"count": len(out),
-		"paths": out,
-	}, "", "  ")
-
-	return core.ToolResult{Content: string(b)}, nil
+	var sb strings.Builder
+	header := base
+	if header == "" {
+		header = "."
+	}
+	fmt.Fprintf(&sb, "%s/ (%d files, %s)\n", header, root.files, humanSize(root.size))
+	lines, skipped := 0, 0
+	var render func(n *treeNode, depth int)
+	render = func(n *treeNode, depth int) {
+		for _, c := range sortedChildren(n) {
+			if lines >= input.Max {
+				skipped++
+				continue
+			}
+			lines++
+			indent := strings.Repeat("  ", depth)
+			if !c.dir {
+				fmt.Fprintf(&sb, "%s%s (%s)\n", indent, c.name, humanSize(c.size))
+				continue
+			}
+			fmt.Fprintf(&sb, "%s%s/ (%d files, %s)\n", indent, c.name, c.files, humanSize(c.size))
+			if depth+1 < input.MaxDepth {
+				render(c, depth+1)
+			}
+		}
+	}
+	render(root, 0)
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "...(%d more entries not shown; list a sub directory with path)\n", skipped)
+	}
+	return core.ToolResult{
+		Content:  sb.String(),
+		Metadata: map[string]any{"files": root.files, "bytes": root.size, "truncated": skipped > 0},
+	}, nil
 }
 
-var errStopWalk = fmt.Errorf("stop walk")
+func sortedChildren(n *treeNode) []*treeNode {
+	out := make([]*treeNode, 0, len(n.children))
+	for _, c := range n.children {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
 
-// MVP glob matching: supports "**" via a tiny helper (no external dep).
-// We treat "**" as "match any segments".
-func doublestarMatch(pattern, path string) (bool, error) {
-	// Fast path: no ** => filepath.Match semantics (slash-normalized).
-	if !strings.Contains(pattern, "**") {
-		return filepath.Match(pattern, path)
+// matchPathGlob matches rel against glob; an empty glob matches everything and
+// a glob without "/" is matched against the base name only.
+func matchPathGlob(glob, rel string) bool {
+	if glob == "" {
+		return true
 	}
+	if !strings.Contains(glob, "/") {
+		return util.MatchGlob(glob, path.Base(rel))
+	}
+	return util.MatchGlob(strings.TrimPrefix(glob, "./"), rel)
+}
 
-	// Very small implementation: split on ** and check ordered substrings.
-	parts := strings.Split(pattern, "**")
-	idx := 0
-	for i, p := range parts {
-		if p == "" {
-			continue
-		}
-		j := strings.Index(path[idx:], p)
-		if j < 0 {
-			return false, nil
-		}
-		if i == 0 && !strings.HasPrefix(pattern, "**") && j != 0 {
-			return false, nil
-		}
-		idx += j + len(p)
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
-	if !strings.HasSuffix(pattern, "**") && parts[len(parts)-1] != "" && idx != len(path) {
-		// pattern tail must align to end if it didn't end with **
-		return false, nil
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
 	}
-	return true, nil
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
 }
@@ -0,0 +1,63 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type MakeDirTool struct {
+	guard pathGuard
+}
+
+func NewMakeDirTool(repoRoot string, protected []string) *MakeDirTool {
+	return &MakeDirTool{guard: pathGuard{repoRoot: repoRoot, protected: protected}}
+}
+
+func (t *MakeDirTool) Name() string { return "make_dir" }
+func (t *MakeDirTool) Description() string {
+	return "Create a directory (and any missing parents) under the repo root."
+}
+func (t *MakeDirTool) Mutating() bool { return true }
+func (t *MakeDirTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Directory path relative to repo root.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type makeDirInput struct {
+	Path string `json:"path"`
+}
+
+func (t *MakeDirTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input makeDirInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Path == "" {
+		return core.ToolResult{}, errors.New("missing path")
+	}
+
+	p, err := t.guard.resolve(input.Path)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	if err := os.MkdirAll(p, 0o755); err != nil {
+		return core.ToolResult{}, err
+	}
+	return core.ToolResult{
+		Content:  fmt.Sprintf("Created directory %s.", input.Path),
+		Metadata: map[string]any{"path": input.Path},
+	}, nil
+}
@@ -0,0 +1,104 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type MoveFileTool struct {
+	guard pathGuard
+}
+
+func NewMoveFileTool(repoRoot string, protected []string) *MoveFileTool {
+	return &MoveFileTool{guard: pathGuard{repoRoot: repoRoot, protected: protected}}
+}
+
+func (t *MoveFileTool) Name() string { return "move_file" }
+func (t *MoveFileTool) Description() string {
+	return "Move or rename a file or directory under the repo root."
+}
+func (t *MoveFileTool) Mutating() bool { return true }
+func (t *MoveFileTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"from": map[string]any{
+				"type":        "string",
+				"description": "Source path relative to repo root.",
+			},
+			"to": map[string]any{
+				"type":        "string",
+				"description": "Destination path relative to repo root.",
+			},
+			"overwrite": map[string]any{
+				"type":        "boolean",
+				"description": "Replace an existing destination file (default false).",
+			},
+		},
+		"required": []string{"from", "to"},
+	}
+}
+
+type moveFileInput struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+func (t *MoveFileTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input moveFileInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.From == "" || input.To == "" {
+		return core.ToolResult{}, errors.New("missing from or to")
+	}
+
+	src, err := t.guard.resolve(input.From)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	dst, err := t.guard.resolve(input.To)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	if _, err := os.Lstat(src); err != nil {
+		return core.ToolResult{}, err
+	}
+	if err := t.guard.checkTree(input.From, src); err != nil {
+		return core.ToolResult{}, err
+	}
+	if info, err := os.Lstat(dst); err == nil {
+		if info.IsDir() || !input.Overwrite {
+			return core.ToolResult{}, fmt.Errorf("%s already exists", input.To)
+		}
+	}
+	before := fileSHA256(src)
+
+	select {
+	case <-ctx.Done():
+		return core.ToolResult{}, ctx.Err()
+	default:
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return core.ToolResult{}, err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return core.ToolResult{}, err
+	}
+
+	core.ChangeSetFrom(ctx).Add(input.From, input.To)
+	meta := hashMetadata(input.To, before, fileSHA256(dst))
+	meta["from"] = input.From
+	return core.ToolResult{
+		Content:  fmt.Sprintf("Moved %s to %s.", input.From, input.To),
+		Metadata: meta,
+	}, nil
+}
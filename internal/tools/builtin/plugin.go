@@ -0,0 +1,348 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/answerlayer/rlmkit/internal/sandbox"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+// PluginDir is where executable plugins are discovered, relative to the repo root.
+const PluginDir = ".rlmkit/tools"
+
+const (
+	pluginDescribeTimeout = 10 * time.Second
+	pluginMaxOutput       = 1 << 20
+)
+
+// PluginConfig declares an executable tool plugin ("plugins" in rlmkit.json).
+// Command is looked up on PATH unless it contains a slash, in which case a
+// relative path is taken from the repo root.
+type PluginConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// TimeoutSec bounds each call (default 60).
+	TimeoutSec int `json:"timeout_sec,omitempty"`
+	// MaxOutputBytes caps what the plugin may write to stdout (default 1 MiB).
+	MaxOutputBytes int  `json:"max_output_bytes,omitempty"`
+	Disabled       bool `json:"disabled,omitempty"`
+}
+
+// pluginDescription is what a plugin prints for --describe.
+type pluginDescription struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema"`
+	ReadOnly    bool            `json:"read_only"`
+}
+
+// pluginOutput is what a plugin prints for a call: a core.ToolResult, plus
+// an optional error and the files it changed.
+type pluginOutput struct {
+	Content      string         `json:"content"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	ChangedFiles []string       `json:"changed_files,omitempty"`
+}
+
+// PluginTool runs an external executable as a tool. The input JSON is written
+// to its stdin and a ToolResult JSON object is read from its stdout.
+type PluginTool struct {
+	cfg       PluginConfig
+	desc      pluginDescription
+	guard     pathGuard
+	sandbox   sandbox.Config
+	timeout   time.Duration
+	maxOutput int
+}
+
+var pluginNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// NewPluginTool runs the plugin with --describe and wraps it. The sandbox
+// for calls is looked up by the tool name the plugin reports.
+func NewPluginTool(ctx context.Context, repoRoot string, protected []string, sandboxes map[string]sandbox.Config, cfg PluginConfig) (*PluginTool, error) {
+	if cfg.Command == "" {
+		return nil, errors.New("missing command")
+	}
+	cfg.Command = pluginCommand(repoRoot, cfg.Command)
+	t := &PluginTool{
+		cfg:       cfg,
+		guard:     pathGuard{repoRoot: repoRoot, protected: protected},
+		timeout:   time.Duration(cfg.TimeoutSec) * time.Second,
+		maxOutput: cfg.MaxOutputBytes,
+	}
+	if t.timeout <= 0 {
+		t.timeout = 60 * time.Second
+	}
+	if t.maxOutput <= 0 {
+		t.maxOutput = pluginMaxOutput
+	}
+
+	out, err := t.run(ctx, pluginDescribeTimeout, append(append([]string{}, cfg.Args...), "--describe"), nil, sandbox.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("--describe: %w", err)
+	}
+	if err := json.Unmarshal(out, &t.desc); err != nil {
+		return nil, fmt.Errorf("--describe: invalid JSON: %w", err)
+	}
+	if !pluginNameRe.MatchString(t.desc.Name) {
+		return nil, fmt.Errorf("--describe: invalid tool name %q", t.desc.Name)
+	}
+	if len(t.desc.Schema) > 0 && string(t.desc.Schema) != "null" {
+		var schema map[string]any
+		if err := json.Unmarshal(t.desc.Schema, &schema); err != nil {
+			return nil, fmt.Errorf("--describe: schema must be a JSON object: %w", err)
+		}
+	}
+	t.sandbox = sandboxes[t.desc.Name]
+	return t, nil
+}
+
+func (t *PluginTool) Name() string { return t.desc.Name }
+func (t *PluginTool) Description() string {
+	if t.desc.Description == "" {
+		return fmt.Sprintf("Plugin tool %s.", filepath.Base(t.cfg.Command))
+	}
+	return t.desc.Description
+}
+func (t *PluginTool) InputSchema() any {
+	if len(t.desc.Schema) == 0 || string(t.desc.Schema) == "null" {
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return t.desc.Schema
+}
+
+// Mutating is true unless the plugin declares itself read-only.
+func (t *PluginTool) Mutating() bool { return !t.desc.ReadOnly }
+
+func (t *PluginTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	if len(in) == 0 || string(in) == "null" {
+		in = json.RawMessage("{}")
+	}
+	if err := t.checkPaths(in); err != nil {
+		return core.ToolResult{}, err
+	}
+
+	out, err := t.run(ctx, t.timeout, t.cfg.Args, in, t.sandbox)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	var res pluginOutput
+	if err := json.Unmarshal(out, &res); err != nil {
+		return core.ToolResult{}, fmt.Errorf("%s: invalid result JSON: %v", t.desc.Name, err)
+	}
+	for _, p := range res.ChangedFiles {
+		if _, err := util.ResolvePathWithinRoot(t.guard.repoRoot, p); err == nil {
+			core.ChangeSetFrom(ctx).Add(filepath.ToSlash(filepath.Clean(p)))
+		}
+	}
+	if res.Error != "" {
+		return core.ToolResult{}, errors.New(res.Error)
+	}
+	if len(res.Content) > 20000 {
+		res.Content = res.Content[:20000] + "...(truncated)"
+	}
+	return core.ToolResult{Content: res.Content, Metadata: res.Metadata}, nil
+}
+
+// checkPaths applies the file tools' rules to the conventional path
+// arguments: they must stay inside the repo, and a mutating plugin may not
+// be pointed at protected paths.
+func (t *PluginTool) checkPaths(in json.RawMessage) error {
+	var args map[string]any
+	if err := json.Unmarshal(in, &args); err != nil {
+		return fmt.Errorf("input must be a JSON object: %w", err)
+	}
+	var paths []string
+	for _, key := range []string{"path", "file", "from", "to", "paths", "files"} {
+		switch v := args[key].(type) {
+		case string:
+			paths = append(paths, v)
+		case []any:
+			for _, p := range v {
+				if s, ok := p.(string); ok {
+					paths = append(paths, s)
+				}
+			}
+		}
+	}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		var err error
+		if t.Mutating() {
+			_, err = t.guard.resolve(p)
+		} else {
+			_, err = util.ResolvePathWithinRoot(t.guard.repoRoot, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run starts the plugin in the repo root and returns its stdout. A non-zero
+// exit is an error carrying the plugin's stderr.
+func (t *PluginTool) run(ctx context.Context, timeout time.Duration, args []string, stdin []byte, sb sandbox.Config) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, t.cfg.Command, args...)
+	cmd.Dir = t.guard.repoRoot
+	cmd.Env = append(os.Environ(), "RLMKIT_REPO_ROOT="+t.guard.repoRoot)
+	for k, v := range t.cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+os.ExpandEnv(v))
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	out := &limitedWriter{buf: &stdout, max: t.maxOutput, full: cancel}
+	cmd.Stdout = out
+	cmd.Stderr = &limitedWriter{buf: &stderr, max: 4096}
+	// Children that inherited stdout must not keep Wait from returning.
+	cmd.WaitDelay = time.Second
+	if err := sandbox.Wrap(cmd, sb, t.guard.repoRoot); err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	err := cmd.Wait()
+	switch {
+	case out.overflow:
+		return nil, fmt.Errorf("output exceeds %d bytes", t.maxOutput)
+	case ctx.Err() == context.DeadlineExceeded:
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case err != nil:
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%v: %s", err, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedWriter keeps the first max bytes and discards the rest. If full is
+// set, it is called once the limit is exceeded.
+type limitedWriter struct {
+	buf      *bytes.Buffer
+	max      int
+	full     func()
+	overflow bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	room := w.max - w.buf.Len()
+	if len(p) <= room {
+		return w.buf.Write(p)
+	}
+	if room > 0 {
+		w.buf.Write(p[:room])
+	}
+	if !w.overflow && w.full != nil {
+		w.full()
+	}
+	w.overflow = true
+	return len(p), nil
+}
+
+func pluginCommand(repoRoot, command string) string {
+	if strings.Contains(command, "/") && !filepath.IsAbs(command) {
+		return filepath.Join(repoRoot, command)
+	}
+	return command
+}
+
+// DiscoverPlugins returns a config for each executable file in PluginDir.
+func DiscoverPlugins(repoRoot string) []PluginConfig {
+	entries, err := os.ReadDir(filepath.Join(repoRoot, PluginDir))
+	if err != nil {
+		return nil
+	}
+	var out []PluginConfig
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		out = append(out, PluginConfig{Command: filepath.Join(repoRoot, PluginDir, e.Name())})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Command < out[j].Command })
+	return out
+}
+
+// RegisterPlugins describes the configured plugins (and, when
+// cfg.EnablePluginDir is set, those in PluginDir) concurrently and registers
+// them after the built-ins. A discovered executable that is also configured
+// uses its configuration (so it can be disabled there). A plugin that fails to
+// describe itself, or whose name is already taken, is reported through warn
+// and skipped.
+func RegisterPlugins(ctx context.Context, r *core.Registry, cfg BuiltinConfig, warn func(command string, err error)) {
+	var plugins []PluginConfig
+	configured := map[string]bool{}
+	for _, p := range cfg.Plugins {
+		configured[pluginCommand(cfg.RepoRoot, p.Command)] = true
+		if !p.Disabled {
+			plugins = append(plugins, p)
+		}
+	}
+	if cfg.EnablePluginDir {
+		for _, p := range DiscoverPlugins(cfg.RepoRoot) {
+			if !configured[p.Command] {
+				plugins = append(plugins, p)
+			}
+		}
+	}
+
+	type result struct {
+		tool *PluginTool
+		err  error
+	}
+	results := make([]result, len(plugins))
+	var wg sync.WaitGroup
+	for i, p := range plugins {
+		wg.Add(1)
+		go func(i int, p PluginConfig) {
+			defer wg.Done()
+			t, err := NewPluginTool(ctx, cfg.RepoRoot, cfg.ProtectedPaths, cfg.Sandbox, p)
+			results[i] = result{tool: t, err: err}
+		}(i, p)
+	}
+	wg.Wait()
+
+	for i, res := range results {
+		if res.err != nil {
+			warn(plugins[i].Command, res.err)
+			continue
+		}
+		if _, exists := r.Get(res.tool.Name()); exists {
+			warn(plugins[i].Command, fmt.Errorf("tool %q already exists", res.tool.Name()))
+			continue
+		}
+		r.Register(res.tool)
+	}
+}
@@ -1,26 +1,47 @@
 package builtin
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/answerlayer/rlmkit/internal/tools/core"
 	"github.com/answerlayer/rlmkit/internal/util"
 )
 
+// maxReadImageBytes caps the images read_file attaches.
+const maxReadImageBytes = 5 << 20
+
 type ReadFileTool struct {
 	repoRoot string
+	vision   bool
 }
 
-func NewReadFileTool(repoRoot string) *ReadFileTool {
-	return &ReadFileTool{repoRoot: repoRoot}
+// NewReadFileTool returns the read_file tool. With vision set, png and jpeg
+// files are returned as images instead of being reported as binary.
+func NewReadFileTool(repoRoot string, vision bool) *ReadFileTool {
+	return &ReadFileTool{repoRoot: repoRoot, vision: vision}
 }
 
-func (t *ReadFileTool) Name() string        { return "read_file" }
-func (t *ReadFileTool) Description() string { return "Read a file under the repo root." }
+func (t *ReadFileTool) Name() string { return "read_file" }
+func (t *ReadFileTool) Description() string {
+	if t.vision {
+		return "Read a file under the repo root. Lines are prefixed with their 1-based line number; use start_line/end_line to page through large files. PNG and JPEG files are shown as images."
+	}
+	return "Read a file under the repo root. Lines are prefixed with their 1-based line number; use start_line/end_line to page through large files."
+}
 func (t *ReadFileTool) InputSchema() any {
 	return map[string]any{
 		"type": "object",
@@ -29,9 +50,21 @@ func (t *ReadFileTool) InputSchema() any {
 				"type":        "string",
 				"description": "File path relative to repo root.",
 			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "First line to return, 1-based (default 1).",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "Last line to return, inclusive (default: end of file, subject to max_bytes).",
+			},
+			"line_numbers": map[string]any{
+				"type":        "boolean",
+				"description": "Prefix each line with its line number (default true). Line numbers are not part of the file content.",
+			},
 			"max_bytes": map[string]any{
 				"type":        "integer",
-				"description": "Maximum bytes to read (default 200000).",
+				"description": "Maximum bytes of output (default 200000).",
 			},
 		},
 		"required": []string{"path"},
@@ -39,8 +72,11 @@ func (t *ReadFileTool) InputSchema() any {
 }
 
 type readFileInput struct {
-	Path     string `json:"path"`
-	MaxBytes int64  `json:"max_bytes"`
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	LineNumbers *bool  `json:"line_numbers"`
+	MaxBytes    int64  `json:"max_bytes"`
 }
 
 func (t *ReadFileTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
@@ -54,28 +90,179 @@ func (t *ReadFileTool) Execute(ctx context.Context, in json.RawMessage) (core.To
 	if input.MaxBytes <= 0 {
 		input.MaxBytes = 200_000
 	}
+	if input.EndLine > 0 && input.StartLine > input.EndLine {
+		return core.ToolResult{}, fmt.Errorf("start_line %d is after end_line %d", input.StartLine, input.EndLine)
+	}
 
 	p, err := util.ResolvePathWithinRoot(t.repoRoot, input.Path)
 	if err != nil {
 		return core.ToolResult{}, err
 	}
 
-	f, err := os.Open(p)
+	select {
+	case <-ctx.Done():
+		return core.ToolResult{}, ctx.Err()
+	default:
+	}
+
+	if t.vision && isImagePath(input.Path) {
+		return readImage(p, input.Path)
+	}
+
+	numbers := input.LineNumbers == nil || *input.LineNumbers
+	return readLineRange(p, input.Path, input.StartLine, input.EndLine, input.MaxBytes, numbers)
+}
+
+// readLineRange reads lines [start, end] (1-based, inclusive; end <= 0 means
+// EOF) of the file at abs, stopping early once maxBytes of output is reached.
+// The whole file is scanned so the total line count is always reported.
+func readLineRange(abs, rel string, start, end int, maxBytes int64, numbers bool) (core.ToolResult, error) {
+	f, err := os.Open(abs)
 	if err != nil {
 		return core.ToolResult{}, err
 	}
 	defer f.Close()
 
-	select {
-	case <-ctx.Done():
-		return core.ToolResult{}, ctx.Err()
-	default:
+	info, err := f.Stat()
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	if info.IsDir() {
+		return core.ToolResult{}, fmt.Errorf("%s is a directory", rel)
 	}
 
-	b, err := io.ReadAll(io.LimitReader(f, input.MaxBytes))
+	br := bufio.NewReaderSize(f, 64*1024)
+	head, _ := br.Peek(8000)
+	if isBinary(head) {
+		return core.ToolResult{
+			Content: fmt.Sprintf("%s is a binary file (%d bytes); not shown.\n", rel, info.Size()),
+			Metadata: map[string]any{
+				"path":   rel,
+				"binary": true,
+				"size":   info.Size(),
+			},
+		}, nil
+	}
+
+	if start <= 0 {
+		start = 1
+	}
+
+	var sb strings.Builder
+	total := 0
+	last := 0 // last line written
+	full := false
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			total++
+			inRange := total >= start && (end <= 0 || total <= end)
+			if inRange && !full {
+				text := strings.TrimRight(line, "\r\n")
+				if numbers {
+					text = fmt.Sprintf("%6d\t%s", total, text)
+				}
+				switch {
+				case int64(sb.Len()+len(text)+1) <= maxBytes:
+					sb.WriteString(text)
+					sb.WriteByte('\n')
+					last = total
+				case last == 0:
+					// A single line longer than the budget (e.g. minified code).
+					sb.WriteString(text[:maxBytes])
+					sb.WriteString("...(line truncated)\n")
+					last = total
+					full = true
+				default:
+					full = true
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return core.ToolResult{}, err
+		}
+	}
+
+	meta := map[string]any{
+		"path":        rel,
+		"total_lines": total,
+	}
+	if last == 0 {
+		if total == 0 {
+			return core.ToolResult{Content: fmt.Sprintf("%s is empty.", rel), Metadata: meta}, nil
+		}
+		return core.ToolResult{}, fmt.Errorf("start_line %d is past the end of %s (%d lines)", start, rel, total)
+	}
+	meta["start_line"] = start
+	meta["end_line"] = last
+
+	content := sb.String()
+	if last < total {
+		meta["more"] = true
+		meta["next_start_line"] = last + 1
+		if end <= 0 || last < end {
+			content += fmt.Sprintf("...(truncated at %d bytes; %d of %d lines shown; continue with start_line=%d)\n", maxBytes, last-start+1, total, last+1)
+		} else {
+			content += fmt.Sprintf("...(%d more lines; continue with start_line=%d)\n", total-last, last+1)
+		}
+	}
+	return core.ToolResult{Content: content, Metadata: meta}, nil
+}
+
+func isImagePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+// readImage returns a png or jpeg file as an image, with its size in the
+// content so models without vision still learn something.
+func readImage(abs, rel string) (core.ToolResult, error) {
+	info, err := os.Stat(abs)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	if info.Size() > maxReadImageBytes {
+		return core.ToolResult{}, fmt.Errorf("%s is too large to show (%d bytes; limit %d)", rel, info.Size(), maxReadImageBytes)
+	}
+	data, err := os.ReadFile(abs)
 	if err != nil {
 		return core.ToolResult{}, err
 	}
+	mediaType := http.DetectContentType(data)
+	if mediaType != "image/png" && mediaType != "image/jpeg" {
+		return core.ToolResult{}, fmt.Errorf("%s is not a png or jpeg image (%s)", rel, mediaType)
+	}
+	meta := map[string]any{"path": rel, "media_type": mediaType, "size": info.Size()}
+	content := fmt.Sprintf("%s: %s image, %d bytes", rel, mediaType, info.Size())
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		meta["width"], meta["height"] = cfg.Width, cfg.Height
+		content += fmt.Sprintf(", %dx%d", cfg.Width, cfg.Height)
+	}
+	return core.ToolResult{
+		Content:  content + " (attached).",
+		Metadata: meta,
+		Images:   []core.Image{{MediaType: mediaType, Data: data}},
+	}, nil
+}
 
-	return core.ToolResult{Content: string(b)}, nil
+// isBinary reports whether the sample looks like binary data: it contains a
+// NUL byte or is not valid UTF-8 (ignoring a rune cut off at the end).
+func isBinary(sample []byte) bool {
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size <= 1 {
+			return len(sample) >= utf8.UTFMax
+		}
+		sample = sample[size:]
+	}
+	return false
 }
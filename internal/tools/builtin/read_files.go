@@ -0,0 +1,100 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+// ReadFilesTool reads several files in one call. Per-file failures are
+// reported inline so one bad path does not hide the others.
+type ReadFilesTool struct {
+	repoRoot string
+}
+
+func NewReadFilesTool(repoRoot string) *ReadFilesTool {
+	return &ReadFilesTool{repoRoot: repoRoot}
+}
+
+func (t *ReadFilesTool) Name() string { return "read_files" }
+func (t *ReadFilesTool) Description() string {
+	return "Read several files under the repo root in one call, with line numbers. Use read_file with start_line/end_line to page through a large file."
+}
+func (t *ReadFilesTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "File paths relative to repo root (max 20).",
+			},
+			"max_bytes_per_file": map[string]any{
+				"type":        "integer",
+				"description": "Maximum bytes of output per file (default 50000).",
+			},
+		},
+		"required": []string{"paths"},
+	}
+}
+
+type readFilesInput struct {
+	Paths           []string `json:"paths"`
+	MaxBytesPerFile int64    `json:"max_bytes_per_file"`
+}
+
+func (t *ReadFilesTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input readFilesInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if len(input.Paths) == 0 {
+		return core.ToolResult{}, errors.New("missing paths")
+	}
+	if len(input.Paths) > 20 {
+		return core.ToolResult{}, fmt.Errorf("too many paths (%d); max 20", len(input.Paths))
+	}
+	if input.MaxBytesPerFile <= 0 {
+		input.MaxBytesPerFile = 50_000
+	}
+
+	var sb strings.Builder
+	files := make([]map[string]any, 0, len(input.Paths))
+	for i, rel := range input.Paths {
+		select {
+		case <-ctx.Done():
+			return core.ToolResult{}, ctx.Err()
+		default:
+		}
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(&sb, "==> %s <==\n", rel)
+
+		res, err := t.readOne(rel, input.MaxBytesPerFile)
+		if err != nil {
+			fmt.Fprintf(&sb, "Error: %v\n", err)
+			files = append(files, map[string]any{"path": rel, "error": err.Error()})
+			continue
+		}
+		sb.WriteString(res.Content)
+		files = append(files, res.Metadata)
+	}
+	return core.ToolResult{
+		Content:  sb.String(),
+		Metadata: map[string]any{"files": files},
+	}, nil
+}
+
+func (t *ReadFilesTool) readOne(rel string, maxBytes int64) (core.ToolResult, error) {
+	p, err := util.ResolvePathWithinRoot(t.repoRoot, rel)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+	return readLineRange(p, rel, 1, 0, maxBytes, true)
+}
@@ -1,6 +1,11 @@
 package builtin
 
 import (
+	"os"
+	"path/filepath"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/sandbox"
 	"github.com/answerlayer/rlmkit/internal/session"
 	"github.com/answerlayer/rlmkit/internal/tools/core"
 )
@@ -9,10 +14,16 @@ type BuiltinConfig struct {
 	RepoRoot             string
 	SessionStore         *session.Store
 	SessionID            string
+	ProtectedPaths       []string // paths file-mutating tools must not touch
+	EnableGitCommit      bool
+	GitCommitHooks       bool // run the repo's commit hooks in git_commit
+	EnableRunTests       bool
 	EnableRunCommand     bool
 	AllowedCommandPrefix []string
 	EnableBash           bool
 	AllowedBashPrefix    []string
+	ShellPolicy          ShellPolicy
+	Sandbox              map[string]sandbox.Config // keyed by tool name
 	EnableHTTPGet        bool
 	AllowedURLPrefix     []string
 	EnableDuckDB         bool
@@ -22,15 +33,40 @@ type BuiltinConfig struct {
 	AllowSearchDomain    []string
 	WebSearchMaxResults  int
 	UserPrompter         UserPrompter
+	Plugins              []PluginConfig // registered by RegisterPlugins
+	EnablePluginDir      bool           // also load executables from PluginDir
+	Vision               bool           // the model accepts images (read_file returns them)
 }
 
 func RegisterAll(r *core.Registry, cfg BuiltinConfig) {
 	r.Register(NewListFilesTool(cfg.RepoRoot))
-	r.Register(NewReadFileTool(cfg.RepoRoot))
+	r.Register(NewReadFileTool(cfg.RepoRoot, cfg.Vision))
+	r.Register(NewReadFilesTool(cfg.RepoRoot))
 	r.Register(NewSearchRepoTool(cfg.RepoRoot))
-	r.Register(NewApplyPatchTool(cfg.RepoRoot))
-	r.Register(NewRunCommandTool(cfg.RepoRoot, cfg.EnableRunCommand, cfg.AllowedCommandPrefix))
-	r.Register(NewBashTool(cfg.RepoRoot, cfg.EnableBash, cfg.AllowedBashPrefix))
+	if _, err := os.Stat(filepath.Join(cfg.RepoRoot, "go.mod")); err == nil {
+		idx := gosym.NewCache(cfg.RepoRoot)
+		r.Register(NewGoPackagesTool(idx))
+		r.Register(NewGoDefinitionTool(idx))
+		r.Register(NewGoReferencesTool(idx))
+		r.Register(NewGoMethodsTool(idx))
+		r.Register(NewGoOutlineTool(cfg.RepoRoot, idx))
+		r.Register(NewDiagnosticsTool(cfg.RepoRoot))
+	}
+	r.Register(NewApplyPatchTool(cfg.RepoRoot, cfg.ProtectedPaths))
+	r.Register(NewEditFileTool(cfg.RepoRoot, cfg.ProtectedPaths))
+	r.Register(NewWriteFileTool(cfg.RepoRoot, cfg.ProtectedPaths))
+	r.Register(NewMoveFileTool(cfg.RepoRoot, cfg.ProtectedPaths))
+	r.Register(NewDeleteFileTool(cfg.RepoRoot, cfg.ProtectedPaths))
+	r.Register(NewMakeDirTool(cfg.RepoRoot, cfg.ProtectedPaths))
+	r.Register(NewGitStatusTool(cfg.RepoRoot))
+	r.Register(NewGitDiffTool(cfg.RepoRoot))
+	r.Register(NewGitLogTool(cfg.RepoRoot))
+	r.Register(NewGitShowTool(cfg.RepoRoot))
+	r.Register(NewGitBlameTool(cfg.RepoRoot))
+	r.Register(NewGitCommitTool(cfg.RepoRoot, cfg.ProtectedPaths, cfg.EnableGitCommit, cfg.GitCommitHooks))
+	r.Register(NewRunTestsTool(cfg.RepoRoot, cfg.EnableRunTests, cfg.Sandbox["run_tests"]))
+	r.Register(NewRunCommandTool(cfg.RepoRoot, cfg.EnableRunCommand, cfg.AllowedCommandPrefix, cfg.ShellPolicy, cfg.Sandbox["run_command"]))
+	r.Register(NewBashTool(cfg.RepoRoot, cfg.EnableBash, cfg.AllowedBashPrefix, cfg.ShellPolicy, cfg.Sandbox["bash"]))
 	r.Register(NewHTTPGetTool(cfg.EnableHTTPGet, cfg.AllowedURLPrefix))
 	r.Register(NewDuckDBQueryTool(cfg.RepoRoot, cfg.EnableDuckDB))
 	r.Register(NewWebSearchTool(
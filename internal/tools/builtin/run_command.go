@@ -5,23 +5,25 @@ import (
 	"encoding/json"
 	"errors"
 	"os/exec"
-	"strings"
 	"time"
 
+	"github.com/answerlayer/rlmkit/internal/sandbox"
 	"github.com/answerlayer/rlmkit/internal/tools/core"
 )
 
 type RunCommandTool struct {
-	repoRoot        string
-	enabled         bool
-	allowedPrefixes []string
+	repoRoot  string
+	enabled   bool
+	allowlist commandAllowlist
+	sandbox   sandbox.Config
 }
 
-func NewRunCommandTool(repoRoot string, enabled bool, allowedPrefixes []string) *RunCommandTool {
+func NewRunCommandTool(repoRoot string, enabled bool, allowedPrefixes []string, policy ShellPolicy, sb sandbox.Config) *RunCommandTool {
 	return &RunCommandTool{
-		repoRoot:        repoRoot,
-		enabled:         enabled,
-		allowedPrefixes: allowedPrefixes,
+		repoRoot:  repoRoot,
+		enabled:   enabled,
+		allowlist: commandAllowlist{prefixes: allowedPrefixes, policy: policy},
+		sandbox:   sb,
 	}
 }
 
@@ -29,6 +31,7 @@ func (t *RunCommandTool) Name() string { return "run_command" }
 func (t *RunCommandTool) Description() string {
 	return "Run a command in the repo. Disabled by default; requires allowlist configuration."
 }
+func (t *RunCommandTool) Mutating() bool { return true }
 func (t *RunCommandTool) InputSchema() any {
 	return map[string]any{
 		"type": "object",
@@ -75,8 +78,8 @@ func (t *RunCommandTool) Execute(ctx context.Context, in json.RawMessage) (core.
 		input.TimeoutSec = 60
 	}
 
-	if !t.isAllowed(input.Command, input.Args) {
-		return core.ToolResult{}, errors.New("command is not in allowlist")
+	if err := t.allowlist.checkArgv(append([]string{input.Command}, input.Args...)); err != nil {
+		return core.ToolResult{}, err
 	}
 
 	toolCtx, cancel := context.WithTimeout(ctx, time.Duration(input.TimeoutSec)*time.Second)
@@ -84,6 +87,9 @@ func (t *RunCommandTool) Execute(ctx context.Context, in json.RawMessage) (core.
 
 	cmd := exec.CommandContext(toolCtx, input.Command, input.Args...)
 	cmd.Dir = t.repoRoot
+	if err := sandbox.Wrap(cmd, t.sandbox, t.repoRoot); err != nil {
+		return core.ToolResult{}, err
+	}
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return core.ToolResult{}, errors.New(string(out))
@@ -97,17 +103,3 @@ func (t *RunCommandTool) Execute(ctx context.Context, in json.RawMessage) (core.
 
 	return core.ToolResult{Content: s}, nil
 }
-
-func (t *RunCommandTool) isAllowed(cmd string, args []string) bool {
-	full := strings.TrimSpace(strings.Join(append([]string{cmd}, args...), " "))
-	for _, p := range t.allowedPrefixes {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
-		}
-		if strings.HasPrefix(full, p) {
-			return true
-		}
-	}
-	return false
-}
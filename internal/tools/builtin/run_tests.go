@@ -0,0 +1,446 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/answerlayer/rlmkit/internal/gosym"
+	"github.com/answerlayer/rlmkit/internal/sandbox"
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+	"github.com/answerlayer/rlmkit/internal/util"
+)
+
+type RunTestsTool struct {
+	repoRoot string
+	enabled  bool
+	sandbox  sandbox.Config
+}
+
+func NewRunTestsTool(repoRoot string, enabled bool, sb sandbox.Config) *RunTestsTool {
+	return &RunTestsTool{repoRoot: repoRoot, enabled: enabled, sandbox: sb}
+}
+
+func (t *RunTestsTool) Name() string { return "run_tests" }
+func (t *RunTestsTool) Description() string {
+	return "Run Go tests (go test -json) and return a summary: pass/fail/skip counts, failing tests with their output and file:line, build errors and optional coverage. Disabled by default."
+}
+func (t *RunTestsTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"packages": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Package patterns (default [\"./...\"]), e.g. \"./internal/agent\".",
+			},
+			"run": map[string]any{
+				"type":        "string",
+				"description": "Optional -run regex selecting tests (e.g. \"^TestEngine\").",
+			},
+			"short": map[string]any{
+				"type":        "boolean",
+				"description": "Pass -short.",
+			},
+			"coverage": map[string]any{
+				"type":        "boolean",
+				"description": "Report statement coverage per package.",
+			},
+			"timeout_sec": map[string]any{
+				"type":        "integer",
+				"description": "Timeout seconds (default 300).",
+			},
+			"max_failures": map[string]any{
+				"type":        "integer",
+				"description": "Maximum failing tests to show output for (default 10).",
+			},
+		},
+	}
+}
+
+type runTestsInput struct {
+	Packages    []string `json:"packages"`
+	Run         string   `json:"run"`
+	Short       bool     `json:"short"`
+	Coverage    bool     `json:"coverage"`
+	TimeoutSec  int      `json:"timeout_sec"`
+	MaxFailures int      `json:"max_failures"`
+}
+
+func (t *RunTestsTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	if !t.enabled {
+		return core.ToolResult{}, errors.New("run_tests is disabled (enable explicitly in config)")
+	}
+	var input runTestsInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if len(input.Packages) == 0 {
+		input.Packages = []string{"./..."}
+	}
+	for _, p := range input.Packages {
+		if strings.HasPrefix(p, "-") {
+			return core.ToolResult{}, fmt.Errorf("invalid package %q", p)
+		}
+		if dir := strings.TrimSuffix(p, "..."); dir != "./" && dir != "." && (strings.HasPrefix(p, ".") || filepath.IsAbs(p)) {
+			if _, err := util.ResolvePathWithinRoot(t.repoRoot, dir); err != nil {
+				return core.ToolResult{}, err
+			}
+		}
+	}
+	if input.TimeoutSec <= 0 {
+		input.TimeoutSec = 300
+	}
+	if input.MaxFailures <= 0 {
+		input.MaxFailures = 10
+	}
+
+	args := []string{"test", "-json", "-timeout", strconv.Itoa(input.TimeoutSec) + "s"}
+	if input.Run != "" {
+		args = append(args, "-run", input.Run)
+	}
+	if input.Short {
+		args = append(args, "-short")
+	}
+	if input.Coverage {
+		args = append(args, "-cover")
+	}
+	args = append(args, input.Packages...)
+
+	// go test enforces -timeout itself and reports the hung test; the context
+	// deadline is a backstop for the build and for go test not exiting.
+	toolCtx, cancel := context.WithTimeout(ctx, time.Duration(input.TimeoutSec+30)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(toolCtx, "go", args...)
+	cmd.Dir = t.repoRoot
+	if err := sandbox.Wrap(cmd, t.sandbox, t.repoRoot); err != nil {
+		return core.ToolResult{}, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	start := time.Now()
+	runErr := cmd.Run()
+	if toolCtx.Err() != nil && ctx.Err() != nil {
+		return core.ToolResult{}, ctx.Err()
+	}
+
+	sum := parseGoTestJSON(stdout.Bytes(), gosym.ModulePath(filepath.Join(t.repoRoot, "go.mod")))
+	sum.BuildErrors = appendGoDiagnostics(sum.BuildErrors, stderr.String(), "build")
+	sum.Elapsed = time.Since(start).Round(10 * time.Millisecond).Seconds()
+	if toolCtx.Err() != nil {
+		sum.TimedOut = true
+	}
+	if runErr != nil && sum.empty() && !sum.TimedOut {
+		// go test failed before running anything we could parse (bad flag,
+		// no matching packages, ...).
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = runErr.Error()
+		}
+		return core.ToolResult{}, fmt.Errorf("go test: %s", msg)
+	}
+
+	content := sum.render(input.MaxFailures)
+	if len(content) > 50000 {
+		content = content[:50000] + "...(truncated)"
+	}
+	return core.ToolResult{
+		Content: content,
+		Metadata: map[string]any{
+			"ok":              sum.ok(),
+			"passed":          sum.Passed,
+			"failed":          sum.Failed,
+			"skipped":         sum.Skipped,
+			"failures":        sum.Failures,
+			"build_errors":    sum.BuildErrors,
+			"failed_packages": sum.FailedPackages,
+			"coverage":        sum.Coverage,
+			"timed_out":       sum.TimedOut,
+		},
+	}, nil
+}
+
+// testEvent is one line of `go test -json` (see `go doc test2json`).
+type testEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64
+	Output      string
+	ImportPath  string // build-output events
+	FailedBuild string // import path of the build that failed the package
+}
+
+type testFailure struct {
+	Package string  `json:"package"`
+	Test    string  `json:"test"`
+	File    string  `json:"file,omitempty"`
+	Line    int     `json:"line,omitempty"`
+	Elapsed float64 `json:"elapsed"`
+	Output  string  `json:"output"`
+}
+
+type testSummary struct {
+	Passed, Failed, Skipped int
+	Packages                int
+	Failures                []testFailure
+	BuildErrors             []diagnostic
+	FailedPackages          []string
+	Coverage                map[string]string
+	Elapsed                 float64
+	TimedOut                bool
+}
+
+func (s *testSummary) ok() bool {
+	return len(s.FailedPackages) == 0 && len(s.BuildErrors) == 0 && s.Failed == 0 && !s.TimedOut
+}
+
+func (s *testSummary) empty() bool {
+	return s.Packages == 0 && len(s.BuildErrors) == 0
+}
+
+var (
+	testLogRe   = regexp.MustCompile(`^\s+([\w.\-]+\.go):(\d+): `)
+	testFrameRe = regexp.MustCompile(`^\t(\S+\.go):(\d+)`)
+	coverageRe  = regexp.MustCompile(`coverage: ([\d.]+%) of statements`)
+)
+
+// parseGoTestJSON folds a `go test -json` event stream into a summary.
+// Failures are reported for the innermost failing test only, so a failed
+// subtest is not repeated under its parent.
+func parseGoTestJSON(data []byte, module string) *testSummary {
+	sum := &testSummary{Coverage: map[string]string{}}
+	type key struct{ pkg, test string }
+	output := map[key][]string{}
+	buildOut := map[string][]string{}
+	failedBuild := map[string]string{}
+	pkgDone := map[string]bool{}
+	var failed []key
+	elapsed := map[key]float64{}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	for sc.Scan() {
+		var ev testEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			// Not an event; go test prints build failures this way when it
+			// cannot attribute them to a package.
+			sum.BuildErrors = appendGoDiagnostics(sum.BuildErrors, sc.Text(), "build")
+			continue
+		}
+		k := key{ev.Package, ev.Test}
+		switch ev.Action {
+		case "output":
+			output[k] = append(output[k], ev.Output)
+		case "build-output":
+			buildOut[ev.ImportPath] = append(buildOut[ev.ImportPath], ev.Output)
+		case "pass", "fail", "skip":
+			if ev.Test == "" {
+				if !pkgDone[ev.Package] {
+					pkgDone[ev.Package] = true
+					sum.Packages++
+				}
+				if ev.Action == "fail" {
+					sum.FailedPackages = append(sum.FailedPackages, ev.Package)
+					if ev.FailedBuild != "" {
+						failedBuild[ev.Package] = ev.FailedBuild
+					}
+				}
+				continue
+			}
+			switch ev.Action {
+			case "pass":
+				sum.Passed++
+			case "skip":
+				sum.Skipped++
+			case "fail":
+				sum.Failed++
+				failed = append(failed, k)
+				elapsed[k] = ev.Elapsed
+			}
+		}
+	}
+
+	importPaths := make([]string, 0, len(buildOut))
+	for p := range buildOut {
+		importPaths = append(importPaths, p)
+	}
+	sort.Strings(importPaths)
+	for _, p := range importPaths {
+		sum.BuildErrors = appendGoDiagnostics(sum.BuildErrors, strings.Join(buildOut[p], ""), "build")
+	}
+
+	for k, lines := range output {
+		if k.test != "" {
+			continue
+		}
+		for _, l := range lines {
+			if m := coverageRe.FindStringSubmatch(l); m != nil {
+				sum.Coverage[k.pkg] = m[1]
+			}
+		}
+	}
+
+	isParent := map[key]bool{}
+	for _, k := range failed {
+		for i := strings.LastIndex(k.test, "/"); i > 0; i = strings.LastIndex(k.test[:i], "/") {
+			isParent[key{k.pkg, k.test[:i]}] = true
+		}
+	}
+	hasFailure := map[string]bool{}
+	for _, k := range failed {
+		hasFailure[k.pkg] = true
+		if isParent[k] {
+			continue
+		}
+		f := testFailure{Package: k.pkg, Test: k.test, Elapsed: elapsed[k]}
+		f.Output, f.File, f.Line = testOutput(output[k], pkgDir(k.pkg, module))
+		sum.Failures = append(sum.Failures, f)
+	}
+	// A package can fail without a failing test: a panic in init or
+	// TestMain, a timeout, or a build or setup failure. Build failures are
+	// already listed as build errors unless go printed no file:line for them.
+	for _, p := range sum.FailedPackages {
+		if hasFailure[p] {
+			continue
+		}
+		f := testFailure{Package: p}
+		if ip, ok := failedBuild[p]; ok {
+			text := strings.Join(buildOut[ip], "")
+			if len(appendGoDiagnostics(nil, text, "build")) > 0 {
+				continue
+			}
+			var lines []string
+			for _, l := range buildOut[ip] {
+				if !strings.HasPrefix(l, "# ") {
+					lines = append(lines, l)
+				}
+			}
+			f.Output, f.File, f.Line = testOutput(lines, "")
+		} else {
+			f.Output, f.File, f.Line = testOutput(output[key{p, ""}], pkgDir(p, module))
+		}
+		sum.Failures = append(sum.Failures, f)
+	}
+	return sum
+}
+
+// testOutput drops the framing lines go test adds around a test's output,
+// keeps at most the first 40 lines, and finds the first file:line the test
+// logged (or, for a panic, the first stack frame in the package).
+func testOutput(lines []string, dir string) (string, string, int) {
+	const maxLines = 40
+	var kept []string
+	file, line := "", 0
+	for _, l := range lines {
+		l = strings.TrimRight(l, "\n")
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, "=== RUN") || strings.HasPrefix(t, "=== PAUSE") ||
+			strings.HasPrefix(t, "=== CONT") || strings.HasPrefix(t, "=== NAME") ||
+			strings.HasPrefix(t, "--- FAIL:") || strings.HasPrefix(t, "--- PASS:") || strings.HasPrefix(t, "--- SKIP:") {
+			continue
+		}
+		if strings.HasPrefix(t, "ok ") || t == "FAIL" || t == "PASS" || strings.HasPrefix(t, "FAIL\t") {
+			continue
+		}
+		kept = append(kept, l)
+		if file != "" {
+			continue
+		}
+		if m := testLogRe.FindStringSubmatch(l); m != nil {
+			file = path.Join(dir, m[1])
+			line, _ = strconv.Atoi(m[2])
+		} else if m := testFrameRe.FindStringSubmatch(l); m != nil && dir != "" &&
+			strings.Contains(filepath.ToSlash(m[1]), "/"+dir+"/") {
+			file = path.Join(dir, path.Base(filepath.ToSlash(m[1])))
+			line, _ = strconv.Atoi(m[2])
+		}
+	}
+	for len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
+		kept = kept[:len(kept)-1]
+	}
+	if len(kept) > maxLines {
+		kept = append(kept[:maxLines], fmt.Sprintf("...(%d more lines)", len(kept)-maxLines))
+	}
+	return strings.Join(kept, "\n"), file, line
+}
+
+// pkgDir maps an import path inside module to its repo-relative directory.
+func pkgDir(pkg, module string) string {
+	if module == "" {
+		return ""
+	}
+	if pkg == module {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(pkg, module+"/"); ok {
+		return rest
+	}
+	return ""
+}
+
+func (s *testSummary) render(maxFailures int) string {
+	var sb strings.Builder
+	status := "ok"
+	if !s.ok() {
+		status = "FAIL"
+	}
+	fmt.Fprintf(&sb, "%s: %d passed, %d failed, %d skipped in %d package(s) (%.1fs)\n",
+		status, s.Passed, s.Failed, s.Skipped, s.Packages, s.Elapsed)
+	if s.TimedOut {
+		sb.WriteString("timed out before go test finished\n")
+	}
+	if s.Passed+s.Failed+s.Skipped == 0 && len(s.BuildErrors) == 0 && len(s.FailedPackages) == 0 {
+		sb.WriteString("no tests ran\n")
+	}
+
+	if len(s.BuildErrors) > 0 {
+		sb.WriteString("\nBuild errors:\n")
+		for _, d := range s.BuildErrors {
+			fmt.Fprintf(&sb, "  %s\n", d)
+		}
+	}
+	for i, f := range s.Failures {
+		if i == maxFailures {
+			fmt.Fprintf(&sb, "\n...(%d more failures)\n", len(s.Failures)-maxFailures)
+			break
+		}
+		name := f.Test
+		if name == "" {
+			name = "(package)"
+		}
+		fmt.Fprintf(&sb, "\n--- FAIL: %s %s (%.2fs)", f.Package, name, f.Elapsed)
+		if f.File != "" {
+			fmt.Fprintf(&sb, " at %s:%d", f.File, f.Line)
+		}
+		sb.WriteString("\n")
+		if f.Output != "" {
+			sb.WriteString(f.Output)
+			sb.WriteString("\n")
+		}
+	}
+	if len(s.Coverage) > 0 {
+		pkgs := make([]string, 0, len(s.Coverage))
+		for p := range s.Coverage {
+			pkgs = append(pkgs, p)
+		}
+		sort.Strings(pkgs)
+		sb.WriteString("\nCoverage:\n")
+		for _, p := range pkgs {
+			fmt.Fprintf(&sb, "  %s %s\n", p, s.Coverage[p])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,232 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/answerlayer/rlmkit/internal/ignore"
+)
+
+// goSearchMaxFileSize skips very large files, which are almost never source.
+const goSearchMaxFileSize = 20 << 20
+
+// goSearch is the pure-Go search_repo backend used when rg is not installed.
+// It mirrors rg's defaults and output so results look the same: gitignored,
+// hidden and binary files are skipped, files are searched concurrently and
+// reported in path order as "path:line:text" (context lines as
+// "path-line-text", with "--" between non-adjacent groups).
+func goSearch(ctx context.Context, repoRoot string, input searchRepoInput) ([]string, error) {
+	re, err := compileSearchQuery(input.Query, input.FixedStrings)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := strings.HasPrefix(input.Glob, "!")
+	glob := strings.TrimPrefix(input.Glob, "!")
+	var files []string
+	err = ignore.New(repoRoot).Walk(ctx, "", func(rel string, d fs.DirEntry) error {
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if glob != "" && matchPathGlob(glob, rel) == exclude {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		idx   int
+		lines []string
+	}
+	jobs := make(chan int)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				lines := searchFile(filepath.Join(repoRoot, filepath.FromSlash(files[i])), files[i], re, input)
+				select {
+				case results <- result{idx: i, lines: lines}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Assemble in path order, stopping once the in-order prefix is longer than
+	// the caller will show.
+	perFile := make([][]string, len(files))
+	done := make([]bool, len(files))
+	next, count := 0, 0
+	for r := range results {
+		perFile[r.idx], done[r.idx] = r.lines, true
+		for next < len(files) && done[next] {
+			count += len(perFile[next])
+			next++
+		}
+		if count > input.MaxLines {
+			cancel()
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil && count <= input.MaxLines {
+		return nil, err
+	}
+
+	var out []string
+	for i := 0; i < next; i++ {
+		if len(perFile[i]) == 0 {
+			continue
+		}
+		if input.Context > 0 && !input.FilesOnly && len(out) > 0 {
+			out = append(out, "--")
+		}
+		out = append(out, perFile[i]...)
+	}
+	return out, nil
+}
+
+// compileSearchQuery builds the regex with rg's smart case: case-insensitive
+// unless the query contains an uppercase letter.
+func compileSearchQuery(query string, fixed bool) (*regexp.Regexp, error) {
+	expr := query
+	if fixed {
+		expr = regexp.QuoteMeta(query)
+	}
+	if !hasUpperLiteral(query, fixed) {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return re, nil
+}
+
+// hasUpperLiteral reports whether query has an uppercase letter that is not
+// part of an escape such as \W or \S.
+func hasUpperLiteral(query string, fixed bool) bool {
+	escaped := false
+	for _, r := range query {
+		if !fixed && escaped {
+			escaped = false
+			continue
+		}
+		if !fixed && r == '\\' {
+			escaped = true
+			continue
+		}
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func searchFile(abs, rel string, re *regexp.Regexp, input searchRepoInput) []string {
+	info, err := os.Stat(abs)
+	if err != nil || info.Size() > goSearchMaxFileSize {
+		return nil
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return nil
+	}
+	head := b
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	var matches []int
+	for i, l := range lines {
+		if re.MatchString(strings.TrimSuffix(l, "\r")) {
+			if input.FilesOnly {
+				return []string{rel}
+			}
+			matches = append(matches, i)
+			if input.MaxPerFile > 0 && len(matches) >= input.MaxPerFile {
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	isMatch := make(map[int]bool, len(matches))
+	for _, m := range matches {
+		isMatch[m] = true
+	}
+	var out []string
+	last := -1 // last line index emitted
+	for _, m := range matches {
+		from, to := m-input.Context, m+input.Context
+		if from < 0 {
+			from = 0
+		}
+		if to >= len(lines) {
+			to = len(lines) - 1
+		}
+		if last >= 0 && from > last+1 && input.Context > 0 {
+			out = append(out, "--")
+		}
+		if from <= last {
+			from = last + 1
+		}
+		for i := from; i <= to; i++ {
+			sep := "-"
+			if isMatch[i] {
+				sep = ":"
+			}
+			out = append(out, rel+sep+strconv.Itoa(i+1)+sep+strings.TrimSuffix(lines[i], "\r"))
+		}
+		if to > last {
+			last = to
+		}
+	}
+	return out
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,7 +25,7 @@ func NewSearchRepoTool(repoRoot string) *SearchRepoTool {
 
 func (t *SearchRepoTool) Name() string { return "search_repo" }
 func (t *SearchRepoTool) Description() string {
-	return "Search the repo using ripgrep (rg). Returns matching lines with paths and line numbers."
+	return "Search the repo with a regex (ripgrep, or a built-in Go search when rg is not installed). Returns path:line:text matches; gitignored, hidden and binary files are skipped."
 }
 func (t *SearchRepoTool) InputSchema() any {
 	return map[string]any{
@@ -29,11 +33,27 @@ func (t *SearchRepoTool) InputSchema() any {
 		"properties": map[string]any{
 			"query": map[string]any{
 				"type":        "string",
-				"description": "Search query (ripgrep regex).",
+				"description": "Search query (regex; case-insensitive unless it contains an uppercase letter).",
 			},
 			"glob": map[string]any{
 				"type":        "string",
-				"description": "Optional glob filter (passed to rg as --glob).",
+				"description": "Optional glob filter (e.g. \"*.go\", \"internal/**/*.go\"; prefix with ! to exclude).",
+			},
+			"fixed_strings": map[string]any{
+				"type":        "boolean",
+				"description": "Treat query as a literal string instead of a regex.",
+			},
+			"context": map[string]any{
+				"type":        "integer",
+				"description": "Lines of context to show before and after each match (default 0).",
+			},
+			"files_only": map[string]any{
+				"type":        "boolean",
+				"description": "Only list the paths of files that match.",
+			},
+			"max_per_file": map[string]any{
+				"type":        "integer",
+				"description": "Maximum matches per file (default unlimited).",
 			},
 			"max_lines": map[string]any{
 				"type":        "integer",
@@ -45,9 +65,13 @@ func (t *SearchRepoTool) InputSchema() any {
 }
 
 type searchRepoInput struct {
-	Query    string `json:"query"`
-	Glob     string `json:"glob"`
-	MaxLines int    `json:"max_lines"`
+	Query        string `json:"query"`
+	Glob         string `json:"glob"`
+	FixedStrings bool   `json:"fixed_strings"`
+	Context      int    `json:"context"`
+	FilesOnly    bool   `json:"files_only"`
+	MaxPerFile   int    `json:"max_per_file"`
+	MaxLines     int    `json:"max_lines"`
 }
 
 func (t *SearchRepoTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
@@ -61,30 +85,79 @@ func (t *SearchRepoTool) Execute(ctx context.Context, in json.RawMessage) (core.
 	if input.MaxLines <= 0 {
 		input.MaxLines = 200
 	}
+	if input.Context < 0 {
+		input.Context = 0
+	}
+	if input.Context > 20 {
+		input.Context = 20
+	}
 
 	toolCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	args := []string{"--line-number", "--no-heading", "--smart-case", input.Query, "."}
+	backend := "rg"
+	var lines []string
+	var err error
+	if _, lerr := exec.LookPath("rg"); lerr == nil {
+		lines, err = t.searchRipgrep(toolCtx, input)
+	} else {
+		backend = "go"
+		lines, err = goSearch(toolCtx, t.repoRoot, input)
+	}
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	meta := map[string]any{"backend": backend}
+	if len(lines) > input.MaxLines {
+		lines = append(lines[:input.MaxLines], "...(truncated)")
+		meta["truncated"] = true
+	}
+	return core.ToolResult{Content: strings.Join(lines, "\n"), Metadata: meta}, nil
+}
+
+func (t *SearchRepoTool) searchRipgrep(ctx context.Context, input searchRepoInput) ([]string, error) {
+	args := []string{"--line-number", "--no-heading", "--smart-case", "--color", "never", "--sort", "path", "--no-require-git"}
+	if _, err := os.Stat(filepath.Join(t.repoRoot, ".rlmkitignore")); err == nil {
+		args = append(args, "--ignore-file", ".rlmkitignore")
+	}
 	if input.Glob != "" {
-		args = append([]string{"--glob", input.Glob}, args...)
+		args = append(args, "--glob", input.Glob)
+	}
+	if input.FixedStrings {
+		args = append(args, "--fixed-strings")
 	}
+	if input.Context > 0 {
+		args = append(args, "--context", strconv.Itoa(input.Context))
+	}
+	if input.FilesOnly {
+		args = append(args, "--files-with-matches")
+	}
+	if input.MaxPerFile > 0 {
+		args = append(args, "--max-count", strconv.Itoa(input.MaxPerFile))
+	}
+	args = append(args, "--regexp", input.Query, ".")
 
-	cmd := exec.CommandContext(toolCtx, "rg", args...)
+	cmd := exec.CommandContext(ctx, "rg", args...)
 	cmd.Dir = t.repoRoot
-	out, err := cmd.CombinedOutput()
+	out, err := cmd.Output()
 	if err != nil && len(out) == 0 {
-		// If rg exited non-zero due to no matches, still return empty output.
-		// rg uses exit code 1 for no matches.
-		if ee, ok := err.(*exec.ExitError); ok && ee.ExitCode() == 1 {
-			return core.ToolResult{Content: ""}, nil
+		// rg uses exit code 1 for no matches; exit code 2 with output means
+		// some files could not be read, which is not worth failing over.
+		if ee, ok := err.(*exec.ExitError); ok {
+			if ee.ExitCode() == 1 {
+				return nil, nil
+			}
+			if msg := strings.TrimSpace(string(ee.Stderr)); msg != "" {
+				return nil, fmt.Errorf("rg: %s", msg)
+			}
 		}
-		return core.ToolResult{}, err
+		return nil, err
 	}
 
-	lines := strings.Split(string(out), "\n")
-	if len(lines) > input.MaxLines {
-		lines = lines[:input.MaxLines]
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(strings.TrimPrefix(l, "./"), "\r")
 	}
-	return core.ToolResult{Content: strings.Join(lines, "\n")}, nil
+	return lines, nil
 }
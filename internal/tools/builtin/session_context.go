@@ -39,7 +39,9 @@ func (t *SessionContextTool) InputSchema() any {
 
 func (t *SessionContextTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
 	var req session.SessionContextRequest
-	_ = json.Unmarshal(in, &req)
+	if err := json.Unmarshal(in, &req); err != nil {
+		return core.ToolResult{}, err
+	}
 
 	resp, err := t.store.GetSessionContext(ctx, t.sessionID, req)
 	if err != nil {
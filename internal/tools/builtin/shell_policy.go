@@ -0,0 +1,259 @@
+package builtin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ShellPolicy controls which shell constructs are accepted in addition to the
+// command prefix allowlist. Every simple command in a script (including those
+// inside pipelines, lists, control flow and substitutions) must match an
+// allowlisted prefix on its own.
+type ShellPolicy struct {
+	// AllowRedirects permits file redirections (>, >>, <, heredocs, ...).
+	// fd duplications such as 2>&1 and redirections to /dev/null are always allowed.
+	AllowRedirects bool
+	// AllowSubshells permits ( ... ), $( ... ), backticks, process substitution
+	// and background jobs (&).
+	AllowSubshells bool
+}
+
+type commandAllowlist struct {
+	prefixes []string
+	policy   ShellPolicy
+}
+
+// matches reports whether an argv (already split into words) matches an allowlisted prefix.
+func (a commandAllowlist) matches(argv []string) bool {
+	full := strings.TrimSpace(strings.Join(argv, " "))
+	if full == "" {
+		return false
+	}
+	for _, p := range a.prefixes {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.HasPrefix(full, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkScript parses script as bash and returns an error naming the first
+// command or construct that is not permitted.
+func (a commandAllowlist) checkScript(script string) error {
+	f, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(script), "")
+	if err != nil {
+		return fmt.Errorf("cannot parse script: %w", err)
+	}
+	if len(f.Stmts) == 0 {
+		return errors.New("empty script")
+	}
+
+	var verr error
+	syntax.Walk(f, func(node syntax.Node) bool {
+		if verr != nil {
+			return false
+		}
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			if n.Background && !a.policy.AllowSubshells {
+				verr = fmt.Errorf("background job %q is not allowed by shell policy", printNode(n))
+			}
+		case *syntax.Subshell:
+			if !a.policy.AllowSubshells {
+				verr = fmt.Errorf("subshell %q is not allowed by shell policy", printNode(n))
+			}
+		case *syntax.CmdSubst:
+			if !a.policy.AllowSubshells {
+				verr = fmt.Errorf("command substitution %q is not allowed by shell policy", printNode(n))
+			}
+		case *syntax.ProcSubst:
+			if !a.policy.AllowSubshells {
+				verr = fmt.Errorf("process substitution %q is not allowed by shell policy", printNode(n))
+			}
+		case *syntax.Redirect:
+			if !a.policy.AllowRedirects && !isHarmlessRedirect(n) {
+				verr = fmt.Errorf("redirection %q is not allowed by shell policy", printRedirect(n))
+			}
+		case *syntax.CallExpr:
+			verr = a.checkCall(n)
+		case *syntax.DeclClause:
+			name := n.Variant.Value
+			if !a.matches([]string{name}) {
+				verr = fmt.Errorf("command %q is not in allowlist", printNode(n))
+			}
+		case *syntax.FuncDecl:
+			// A function could shadow an allowlisted command name.
+			verr = fmt.Errorf("function definition %q is not allowed", n.Name.Value)
+		}
+		return verr == nil
+	})
+	return verr
+}
+
+func (a commandAllowlist) checkCall(c *syntax.CallExpr) error {
+	// Assignments, alone or before a command, can change what an allowlisted
+	// command runs (PATH=./x:$PATH, GOFLAGS=-toolexec=...).
+	if len(c.Assigns) > 0 {
+		return fmt.Errorf("variable assignment %q is not allowed by shell policy", printNode(c))
+	}
+	if len(c.Args) == 0 {
+		return nil
+	}
+	name, ok := wordLiteral(c.Args[0])
+	if !ok {
+		return fmt.Errorf("command %q has a non-literal command name", printNode(c))
+	}
+	argv := []string{name}
+	for _, w := range c.Args[1:] {
+		if s, ok := wordLiteral(w); ok {
+			argv = append(argv, s)
+		} else {
+			argv = append(argv, printNode(w))
+		}
+	}
+	if !a.matches(argv) {
+		return fmt.Errorf("command %q is not in allowlist", strings.Join(argv, " "))
+	}
+	return nil
+}
+
+// checkArgv checks a direct exec (no shell). If argv invokes a shell with an
+// inline script (bash -c "..."), the script is checked statement by statement
+// instead of the interpreter itself.
+func (a commandAllowlist) checkArgv(argv []string) error {
+	if script, ok := inlineShellScript(argv); ok {
+		return a.checkScript(script)
+	}
+	if !a.matches(argv) {
+		return fmt.Errorf("command %q is not in allowlist", strings.Join(argv, " "))
+	}
+	return nil
+}
+
+// inlineShellScript returns the script of `sh -c script [name [args...]]`.
+// Only short options from a known-safe set may come before the script; any
+// other shape (a script file operand, --rcfile, -i, -O, ...) could run code
+// other than the script, so it is left to the plain prefix match.
+func inlineShellScript(argv []string) (string, bool) {
+	if len(argv) < 3 {
+		return "", false
+	}
+	// Scripts are parsed as bash; zsh is left out since its syntax (glob
+	// qualifiers such as *(e:...:)) can run code bash would not.
+	switch filepath.Base(argv[0]) {
+	case "sh", "bash", "dash":
+	default:
+		return "", false
+	}
+	inline := false
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if arg == "--" {
+			if inline && i+1 < len(argv) {
+				return argv[i+1], true
+			}
+			return "", false
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			// The first operand: the script when -c was given.
+			return arg, inline
+		}
+		if strings.HasPrefix(arg, "--") {
+			return "", false
+		}
+		for _, r := range arg[1:] {
+			if !strings.ContainsRune(safeShellOptions, r) {
+				return "", false
+			}
+			if r == 'c' {
+				inline = true
+			}
+		}
+	}
+	return "", false
+}
+
+// safeShellOptions are the single-letter shell options that neither read
+// other files nor change which code runs: -c, -e, -l, -u, -v, -x.
+const safeShellOptions = "celuvx"
+
+func isHarmlessRedirect(r *syntax.Redirect) bool {
+	target, ok := wordLiteral(r.Word)
+	if !ok {
+		return false
+	}
+	switch r.Op {
+	case syntax.DplIn, syntax.DplOut:
+		return target == "-" || isDigits(target)
+	case syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.RdrIn:
+		return target == "/dev/null"
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// wordLiteral returns the unquoted value of w if it contains no expansions.
+func wordLiteral(w *syntax.Word) (string, bool) {
+	if w == nil {
+		return "", false
+	}
+	var sb strings.Builder
+	for _, part := range w.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			if p.Dollar {
+				return "", false
+			}
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, dp := range p.Parts {
+				lit, ok := dp.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+func printRedirect(r *syntax.Redirect) string {
+	s := r.Op.String() + printNode(r.Word)
+	if r.N != nil {
+		s = r.N.Value + s
+	}
+	return s
+}
+
+func printNode(n syntax.Node) string {
+	var buf bytes.Buffer
+	if err := syntax.NewPrinter(syntax.SingleLine(true)).Print(&buf, n); err != nil {
+		return fmt.Sprintf("%T", n)
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,57 @@
+package builtin
+
+import "testing"
+
+func TestCheckArgvInlineScript(t *testing.T) {
+	a := commandAllowlist{prefixes: []string{"go test"}}
+	cases := []struct {
+		argv []string
+		ok   bool
+	}{
+		{[]string{"bash", "-c", "go test ./..."}, true},
+		{[]string{"bash", "-lc", "go test ./..."}, true},
+		{[]string{"sh", "-e", "-c", "go test ./...", "name", "arg"}, true},
+		{[]string{"bash", "-c", "--", "go test ./..."}, true},
+		{[]string{"bash", "-c", "rm -rf ."}, false},
+
+		// A script operand before -c: bash runs the file, -c is its argument.
+		{[]string{"bash", "./evil.sh", "-c", "go test"}, false},
+		// Options that read or run other code.
+		{[]string{"bash", "--rcfile", "./evil.sh", "-ic", "go test"}, false},
+		{[]string{"bash", "--init-file", "./evil.sh", "-ic", "go test"}, false},
+		{[]string{"bash", "-ic", "go test"}, false},
+		{[]string{"bash", "-O", "extglob", "-c", "go test"}, false},
+		{[]string{"bash", "-o", "posix", "-c", "go test"}, false},
+		{[]string{"bash", "-s", "-c", "go test"}, false},
+		{[]string{"bash", "+e", "-c", "go test"}, false},
+		{[]string{"bash", "-e", "go test"}, false},
+		// zsh syntax is not what the bash parser sees.
+		{[]string{"zsh", "-c", "go test *(e:'touch /tmp/pwn':)"}, false},
+	}
+	for _, c := range cases {
+		err := a.checkArgv(c.argv)
+		if (err == nil) != c.ok {
+			t.Errorf("checkArgv(%q) = %v, want ok=%v", c.argv, err, c.ok)
+		}
+	}
+}
+
+func TestCheckScriptAssignments(t *testing.T) {
+	a := commandAllowlist{prefixes: []string{"go test"}}
+	cases := []struct {
+		script string
+		ok     bool
+	}{
+		{"go test ./...", true},
+		{"go test ./... && go test -run X ./...", true},
+		{"PATH=./x:$PATH go test ./...", false},
+		{"GOFLAGS=-toolexec=./evil go test ./...", false},
+		{"GOFLAGS=-toolexec=./evil; go test ./...", false},
+	}
+	for _, c := range cases {
+		err := a.checkScript(c.script)
+		if (err == nil) != c.ok {
+			t.Errorf("checkScript(%q) = %v, want ok=%v", c.script, err, c.ok)
+		}
+	}
+}
@@ -26,7 +26,10 @@ type webSearchProvider interface {
 	Search(ctx context.Context, q string, count int, params webSearchInput) ([]searchResult, error)
 }
 
+// WebSearchTool takes its name, description and schema from the embedded
+// typed tool; webSearchInput's tags are the single source for them.
 type WebSearchTool struct {
+	*core.TypedTool[webSearchInput]
 	enabled        bool
 	providerName   string
 	provider       webSearchProvider
@@ -56,52 +59,27 @@ func NewWebSearchTool(enabled bool, providerName string, braveToken string, allo
 		// Keep nil; tool will return a clear error on use.
 	}
 
-	return &WebSearchTool{
+	t := &WebSearchTool{
 		enabled:        enabled,
 		providerName:   pn,
 		provider:       p,
 		allowedDomains: allowedDomains,
 		maxResults:     maxResults,
 	}
-}
-
-func (t *WebSearchTool) Name() string { return "web_search" }
-func (t *WebSearchTool) Description() string {
-	return "Search the web and return normalized results. Disabled by default; provider and API key required."
-}
-func (t *WebSearchTool) InputSchema() any {
-	return map[string]any{
-		"type": "object",
-		"properties": map[string]any{
-			"query": map[string]any{
-				"type":        "string",
-				"description": "Search query.",
-			},
-			"count": map[string]any{
-				"type":        "integer",
-				"description": "Number of results (default 5; capped by config).",
-			},
-			"freshness": map[string]any{
-				"type":        "string",
-				"description": "Optional freshness hint (e.g. 'day', 'week', 'month'). Provider-specific.",
-			},
-			"country": map[string]any{
-				"type":        "string",
-				"description": "Optional country code (e.g. 'US'). Provider-specific.",
-			},
-		},
-		"required": []string{"query"},
-	}
+	t.TypedTool = core.NewTypedTool("web_search",
+		"Search the web and return normalized results. Disabled by default; provider and API key required.",
+		t.search)
+	return t
 }
 
 type webSearchInput struct {
-	Query     string `json:"query"`
-	Count     int    `json:"count"`
-	Freshness string `json:"freshness"`
-	Country   string `json:"country"`
+	Query     string `json:"query" required:"true" desc:"Search query."`
+	Count     int    `json:"count" default:"5" minimum:"1" desc:"Number of results (capped by config)."`
+	Freshness string `json:"freshness" desc:"Optional freshness hint (e.g. 'day', 'week', 'month'). Provider-specific."`
+	Country   string `json:"country" desc:"Optional country code (e.g. 'US'). Provider-specific."`
 }
 
-func (t *WebSearchTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+func (t *WebSearchTool) search(ctx context.Context, input webSearchInput) (core.ToolResult, error) {
 	if !t.enabled {
 		return core.ToolResult{}, errors.New("web_search is disabled (enable explicitly in config)")
 	}
@@ -109,17 +87,10 @@ func (t *WebSearchTool) Execute(ctx context.Context, in json.RawMessage) (core.T
 		return core.ToolResult{}, fmt.Errorf("web_search provider '%s' is not supported", t.providerName)
 	}
 
-	var input webSearchInput
-	if err := json.Unmarshal(in, &input); err != nil {
-		return core.ToolResult{}, err
-	}
 	q := strings.TrimSpace(input.Query)
 	if q == "" {
 		return core.ToolResult{}, errors.New("missing query")
 	}
-	if input.Count <= 0 {
-		input.Count = 5
-	}
 	if input.Count > t.maxResults {
 		input.Count = t.maxResults
 	}
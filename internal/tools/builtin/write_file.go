@@ -0,0 +1,92 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/answerlayer/rlmkit/internal/tools/core"
+)
+
+type WriteFileTool struct {
+	guard pathGuard
+}
+
+func NewWriteFileTool(repoRoot string, protected []string) *WriteFileTool {
+	return &WriteFileTool{guard: pathGuard{repoRoot: repoRoot, protected: protected}}
+}
+
+func (t *WriteFileTool) Name() string { return "write_file" }
+func (t *WriteFileTool) Description() string {
+	return "Create or overwrite a file under the repo root with the given content. Parent directories are created."
+}
+func (t *WriteFileTool) Mutating() bool { return true }
+func (t *WriteFileTool) InputSchema() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File path relative to repo root.",
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "Full file content.",
+			},
+		},
+		"required": []string{"path", "content"},
+	}
+}
+
+type writeFileInput struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+func (t *WriteFileTool) Execute(ctx context.Context, in json.RawMessage) (core.ToolResult, error) {
+	var input writeFileInput
+	if err := json.Unmarshal(in, &input); err != nil {
+		return core.ToolResult{}, err
+	}
+	if input.Path == "" {
+		return core.ToolResult{}, errors.New("missing path")
+	}
+
+	p, err := t.guard.resolve(input.Path)
+	if err != nil {
+		return core.ToolResult{}, err
+	}
+
+	mode := os.FileMode(0o644)
+	verb := "Created"
+	if info, err := os.Stat(p); err == nil {
+		if info.IsDir() {
+			return core.ToolResult{}, fmt.Errorf("%s is a directory", input.Path)
+		}
+		mode = info.Mode().Perm()
+		verb = "Overwrote"
+	}
+	before := fileSHA256(p)
+
+	select {
+	case <-ctx.Done():
+		return core.ToolResult{}, ctx.Err()
+	default:
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return core.ToolResult{}, err
+	}
+	if err := os.WriteFile(p, []byte(input.Content), mode); err != nil {
+		return core.ToolResult{}, err
+	}
+
+	core.ChangeSetFrom(ctx).Add(input.Path)
+	return core.ToolResult{
+		Content:  fmt.Sprintf("%s %s (%d bytes).", verb, input.Path, len(input.Content)),
+		Metadata: hashMetadata(input.Path, before, fileSHA256(p)),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// ChangeSet records the repo-relative paths modified during one agent turn.
+// The engine attaches one to the context of every tool call in a turn; file
+// tools add the paths they write so later calls (e.g. diagnostics) can scope
+// their work to the turn's edits. A nil *ChangeSet ignores additions.
+type ChangeSet struct {
+	mu    sync.Mutex
+	paths map[string]struct{}
+}
+
+// Add records paths as modified.
+func (c *ChangeSet) Add(paths ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paths == nil {
+		c.paths = map[string]struct{}{}
+	}
+	for _, p := range paths {
+		if p != "" {
+			c.paths[p] = struct{}{}
+		}
+	}
+}
+
+// Paths returns the recorded paths, sorted.
+func (c *ChangeSet) Paths() []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]string, 0, len(c.paths))
+	for p := range c.paths {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type changeSetKey struct{}
+
+// WithChangeSet returns a context carrying c.
+func WithChangeSet(ctx context.Context, c *ChangeSet) context.Context {
+	return context.WithValue(ctx, changeSetKey{}, c)
+}
+
+// ChangeSetFrom returns the turn's change set, or nil outside a turn.
+func ChangeSetFrom(ctx context.Context) *ChangeSet {
+	c, _ := ctx.Value(changeSetKey{}).(*ChangeSet)
+	return c
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RepairJSON fixes the damage models commonly do to tool-call arguments:
+// a ```json fence around the object, trailing commas, text after the closing
+// brace, and output cut off mid-value (an unterminated string, a dangling key
+// or missing closing brackets). truncated reports that the input was cut off,
+// so values may be incomplete; ok is false if the result is still not valid
+// JSON.
+func RepairJSON(s string) (out string, truncated, ok bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s[3:], "json")
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+	}
+
+	type level struct {
+		close     byte
+		open      int // output index just after the opening bracket
+		lastComma int // output index of the last separating comma, or -1
+	}
+	var (
+		buf      []byte
+		stack    []level
+		inString bool
+		escaped  bool
+		comma    bool // a comma is pending until the next token shows it is not trailing
+		started  bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			buf = append(buf, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			continue
+		}
+		if started && len(stack) == 0 {
+			break // trailing text after a complete value
+		}
+		if c == ',' {
+			comma = true
+			continue
+		}
+		if comma {
+			comma = false
+			if c != '}' && c != ']' && len(stack) > 0 {
+				stack[len(stack)-1].lastComma = len(buf)
+				buf = append(buf, ',')
+			}
+		}
+		started = true
+		buf = append(buf, c)
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, level{close: '}', open: len(buf), lastComma: -1})
+		case '[':
+			stack = append(stack, level{close: ']', open: len(buf), lastComma: -1})
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if inString {
+		if escaped {
+			buf = buf[:len(buf)-1]
+		}
+		buf = append(buf, '"')
+	}
+
+	closeAll := func(b []byte, levels []level) []byte {
+		for i := len(levels) - 1; i >= 0; i-- {
+			b = append(b, levels[i].close)
+		}
+		return b
+	}
+	truncated = inString || len(stack) > 0
+	candidate := closeAll(append([]byte{}, buf...), stack)
+	if json.Valid(candidate) {
+		return string(candidate), truncated, true
+	}
+	// The cut may have left a partial member, such as a key without a value
+	// or a truncated literal. Drop it, working outwards one level at a time.
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.lastComma >= 0 {
+			buf = buf[:top.lastComma]
+		} else {
+			buf = buf[:top.open]
+		}
+		candidate = closeAll(append([]byte{}, buf...), stack)
+		if json.Valid(candidate) {
+			return string(candidate), truncated, true
+		}
+		if top.lastComma < 0 {
+			buf = buf[:top.open-1]
+			stack = stack[:len(stack)-1]
+		} else {
+			stack[len(stack)-1].lastComma = -1
+		}
+	}
+	return "", truncated, false
+}
@@ -0,0 +1,392 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxSchemaErrors caps how many problems one validation reports.
+const maxSchemaErrors = 10
+
+// PrepareArgs turns the raw arguments a model produced for t into the input
+// passed to Execute. Empty arguments become {}, damaged JSON is repaired when
+// possible (repaired reports whether it was), and the result is validated
+// against t's InputSchema. Arguments that were cut off are only repaired for
+// tools that do not mutate, since a shortened value could be written out.
+func PrepareArgs(t Tool, raw string) (in json.RawMessage, repaired bool, err error) {
+	if strings.TrimSpace(raw) == "" {
+		raw = "{}"
+	}
+	if !json.Valid([]byte(raw)) {
+		fixed, truncated, ok := RepairJSON(raw)
+		if !ok {
+			var v any
+			err := json.Unmarshal([]byte(raw), &v)
+			return nil, false, fmt.Errorf("arguments are not valid JSON: %v", err)
+		}
+		if truncated && IsMutating(t) {
+			return nil, false, errors.New("arguments are not valid JSON: they appear to be cut off; send the complete call again")
+		}
+		raw, repaired = fixed, true
+	}
+	in = json.RawMessage(raw)
+	return in, repaired, ValidateJSON(t.InputSchema(), in)
+}
+
+// ValidateJSON checks in against a JSON schema given as a map, a struct that
+// marshals to one, or raw JSON. It supports the subset tools use: type
+// (including type lists), properties, required, additionalProperties, items,
+// enum, minimum/maximum, minLength/maxLength and minItems/maxItems. An object
+// schema that lists properties rejects unknown ones unless it sets
+// additionalProperties, so misspelled arguments are not silently dropped.
+func ValidateJSON(schema any, in json.RawMessage) error {
+	return validateJSON(schema, in, "arguments")
+}
+
+// ValidateValue is ValidateJSON for a value that is not tool input, such as
+// a final answer; messages name it what ("invalid answer: ...").
+func ValidateValue(schema any, in json.RawMessage, what string) error {
+	return validateJSON(schema, in, what)
+}
+
+// CheckSchema returns an error naming the keywords in schema that
+// ValidateJSON does not enforce (anyOf, $ref, pattern, format, ...), so a
+// schema written for a fuller validator is refused rather than silently
+// accepting values it forbids. Annotations such as description are fine.
+func CheckSchema(schema any) error {
+	s, err := schemaMap(schema)
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	var bad []string
+	checkSchemaKeywords(s, "", &bad)
+	if len(bad) == 0 {
+		return nil
+	}
+	return fmt.Errorf("schema uses keywords that are not supported: %s", strings.Join(bad, ", "))
+}
+
+// schemaKeywords are the keywords validateValue enforces or may ignore.
+var schemaKeywords = map[string]bool{
+	"type": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "enum": true, "minimum": true, "maximum": true,
+	"minLength": true, "maxLength": true, "minItems": true, "maxItems": true,
+	// Annotations.
+	"title": true, "description": true, "default": true, "examples": true,
+	"$schema": true, "$id": true, "$comment": true, "readOnly": true, "writeOnly": true, "deprecated": true,
+}
+
+func checkSchemaKeywords(s map[string]any, path string, bad *[]string) {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !schemaKeywords[k] {
+			*bad = append(*bad, fmt.Sprintf("%q at %s", k, schemaPath(path)))
+		}
+	}
+	if props, ok := s["properties"].(map[string]any); ok {
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ps, ok := props[name].(map[string]any); ok {
+				checkSchemaKeywords(ps, path+"/properties/"+name, bad)
+			}
+		}
+	}
+	switch items := s["items"].(type) {
+	case map[string]any:
+		checkSchemaKeywords(items, path+"/items", bad)
+	case []any:
+		*bad = append(*bad, fmt.Sprintf("%q as a list at %s", "items", schemaPath(path)))
+	}
+	if a, ok := s["additionalProperties"].(map[string]any); ok {
+		checkSchemaKeywords(a, path+"/additionalProperties", bad)
+	}
+}
+
+func schemaPath(path string) string {
+	if path == "" {
+		return "the root"
+	}
+	return path
+}
+
+func validateJSON(schema any, in json.RawMessage, what string) error {
+	s, err := schemaMap(schema)
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		if what == "arguments" {
+			return fmt.Errorf("arguments are not valid JSON: %v", err)
+		}
+		return fmt.Errorf("invalid %s: not valid JSON: %v", what, err)
+	}
+	var errs []string
+	validateValue(s, v, what, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) > maxSchemaErrors {
+		errs = append(errs[:maxSchemaErrors], fmt.Sprintf("and %d more", len(errs)-maxSchemaErrors))
+	}
+	return fmt.Errorf("invalid %s: %s", what, strings.Join(errs, "; "))
+}
+
+func schemaMap(schema any) (map[string]any, error) {
+	// Schemas are normalized through JSON: builtins use []string and typed
+	// literals that would otherwise each need handling.
+	var b []byte
+	switch s := schema.(type) {
+	case nil:
+		return map[string]any{}, nil
+	case json.RawMessage:
+		b = s
+	case []byte:
+		b = s
+	default:
+		var err error
+		if b, err = json.Marshal(schema); err != nil {
+			return nil, err
+		}
+	}
+	if len(bytes.TrimSpace(b)) == 0 || string(b) == "null" {
+		return map[string]any{}, nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var integerRe = regexp.MustCompile(`^-?[0-9]+$`)
+
+// jsonType names the JSON type of a value decoded with UseNumber.
+func jsonType(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if integerRe.MatchString(x.String()) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func typeMatches(want, got string) bool {
+	return want == got || (want == "number" && got == "integer")
+}
+
+// describePath names the value at path; root names the whole value.
+func describePath(root, path string) string {
+	if path == "" {
+		return root
+	}
+	return fmt.Sprintf("%q", path)
+}
+
+func validateValue(s map[string]any, v any, root, path string, errs *[]string) {
+	got := jsonType(v)
+	if types := schemaTypes(s["type"]); len(types) > 0 {
+		ok := false
+		for _, t := range types {
+			if typeMatches(t, got) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", describePath(root, path), strings.Join(types, " or "), describeValue(v, got)))
+			return
+		}
+	}
+
+	if enum, ok := s["enum"].([]any); ok && len(enum) > 0 {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not one of %s", describePath(root, path), describeValue(v, got), formatEnum(enum)))
+		}
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		validateObject(s, x, root, path, errs)
+	case []any:
+		if n, ok := schemaNumber(s["minItems"]); ok && float64(len(x)) < n {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at least %v items, got %d", describePath(root, path), n, len(x)))
+		}
+		if n, ok := schemaNumber(s["maxItems"]); ok && float64(len(x)) > n {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at most %v items, got %d", describePath(root, path), n, len(x)))
+		}
+		if items, ok := s["items"].(map[string]any); ok {
+			for i, item := range x {
+				validateValue(items, item, root, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		n := len([]rune(x))
+		if min, ok := schemaNumber(s["minLength"]); ok && float64(n) < min {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at least %v characters, got %d", describePath(root, path), min, n))
+		}
+		if max, ok := schemaNumber(s["maxLength"]); ok && float64(n) > max {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at most %v characters, got %d", describePath(root, path), max, n))
+		}
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return
+		}
+		if min, ok := schemaNumber(s["minimum"]); ok && f < min {
+			*errs = append(*errs, fmt.Sprintf("%s: must be >= %v, got %s", describePath(root, path), min, x))
+		}
+		if max, ok := schemaNumber(s["maximum"]); ok && f > max {
+			*errs = append(*errs, fmt.Sprintf("%s: must be <= %v, got %s", describePath(root, path), max, x))
+		}
+	}
+}
+
+func validateObject(s map[string]any, obj map[string]any, root, path string, errs *[]string) {
+	props, _ := s["properties"].(map[string]any)
+	if req, ok := s["required"].([]any); ok {
+		for _, r := range req {
+			name, _ := r.(string)
+			if _, present := obj[name]; name != "" && !present {
+				*errs = append(*errs, fmt.Sprintf("missing required property %q", joinPath(path, name)))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	additional, hasAdditional := s["additionalProperties"]
+	for _, k := range keys {
+		if p, known := props[k]; known {
+			if ps, ok := p.(map[string]any); ok {
+				validateValue(ps, obj[k], root, joinPath(path, k), errs)
+			}
+			continue
+		}
+		switch a := additional.(type) {
+		case map[string]any:
+			validateValue(a, obj[k], root, joinPath(path, k), errs)
+		case bool:
+			if !a {
+				*errs = append(*errs, unknownProperty(path, k, props))
+			}
+		default:
+			if !hasAdditional && len(props) > 0 {
+				*errs = append(*errs, unknownProperty(path, k, props))
+			}
+		}
+	}
+}
+
+func unknownProperty(path, name string, props map[string]any) string {
+	allowed := make([]string, 0, len(props))
+	for k := range props {
+		allowed = append(allowed, k)
+	}
+	sort.Strings(allowed)
+	return fmt.Sprintf("unknown property %q (allowed: %s)", joinPath(path, name), strings.Join(allowed, ", "))
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func schemaTypes(t any) []string {
+	switch x := t.(type) {
+	case string:
+		return []string{x}
+	case []any:
+		var out []string
+		for _, e := range x {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaNumber(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+// jsonEqual compares a schema enum value (decoded without UseNumber) with an
+// argument value (decoded with it).
+func jsonEqual(schemaVal, v any) bool {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		sf, isNum := schemaVal.(float64)
+		return err == nil && isNum && f == sf
+	}
+	a, _ := json.Marshal(schemaVal)
+	b, _ := json.Marshal(v)
+	return bytes.Equal(a, b)
+}
+
+func describeValue(v any, typ string) string {
+	switch x := v.(type) {
+	case string:
+		if len(x) > 40 {
+			x = x[:40] + "..."
+		}
+		return typ + " " + strconv.Quote(x)
+	case json.Number:
+		return typ + " " + x.String()
+	case bool:
+		return typ + " " + strconv.FormatBool(x)
+	}
+	return typ
+}
+
+func formatEnum(enum []any) string {
+	parts := make([]string, 0, len(enum))
+	for _, e := range enum {
+		b, _ := json.Marshal(e)
+		parts = append(parts, string(b))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
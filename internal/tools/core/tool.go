@@ -12,9 +12,29 @@ type Tool interface {
 	Execute(ctx context.Context, in json.RawMessage) (ToolResult, error)
 }
 
+// MutatingTool is implemented by tools that may modify the working tree.
+// The engine snapshots the repo before the first such call in a turn.
+type MutatingTool interface {
+	Mutating() bool
+}
+
+// IsMutating reports whether t declares itself as mutating.
+func IsMutating(t Tool) bool {
+	m, ok := t.(MutatingTool)
+	return ok && m.Mutating()
+}
+
 type ToolResult struct {
 	Content  string         `json:"content"`
 	Metadata map[string]any `json:"metadata,omitempty"`
+	// Images are shown to the model after Content (see read_file).
+	Images []Image `json:"images,omitempty"`
+}
+
+// Image is image data for a vision-capable model.
+type Image struct {
+	MediaType string `json:"media_type"` // e.g. "image/png"
+	Data      []byte `json:"data"`
 }
 
 type Registry struct {
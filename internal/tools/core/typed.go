@@ -0,0 +1,257 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TypedTool is a Tool whose input is a Go struct. The JSON schema is
+// generated from the struct, and Execute validates and decodes the input
+// before calling the handler. Fields are named by their json tag and
+// described with these tags:
+//
+//	desc:"..."       description
+//	default:"5"      value used when the field is absent (JSON, or a bare string)
+//	enum:"a,b,c"     allowed values
+//	required:"true"  the field must be present
+//	minimum:"1"      numeric bounds (also maximum)
+//
+// Supported field types are strings, bools, integers, floats, slices, maps
+// with string keys, nested and embedded structs, pointers to those and
+// json.RawMessage (any JSON value).
+type TypedTool[In any] struct {
+	name        string
+	description string
+	mutating    bool
+	schema      map[string]any
+	handler     func(ctx context.Context, in In) (ToolResult, error)
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// NewTypedTool builds a tool from a handler. It panics if In is not a struct
+// or its tags are malformed, since that is a programming error.
+func NewTypedTool[In any](name, description string, handler func(ctx context.Context, in In) (ToolResult, error)) *TypedTool[In] {
+	t := &TypedTool[In]{name: name, description: description, handler: handler}
+	var zero In
+	typ := reflect.TypeOf(zero)
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("%s: typed tool input must be a struct, not %v", name, typ))
+	}
+	schema, err := structSchema(typ)
+	if err != nil {
+		panic(fmt.Sprintf("%s: %v", name, err))
+	}
+	t.schema = schema
+	return t
+}
+
+// WithMutating marks the tool as modifying the working tree.
+func (t *TypedTool[In]) WithMutating() *TypedTool[In] {
+	t.mutating = true
+	return t
+}
+
+func (t *TypedTool[In]) Name() string        { return t.name }
+func (t *TypedTool[In]) Description() string { return t.description }
+func (t *TypedTool[In]) InputSchema() any    { return t.schema }
+func (t *TypedTool[In]) Mutating() bool      { return t.mutating }
+
+func (t *TypedTool[In]) Execute(ctx context.Context, in json.RawMessage) (ToolResult, error) {
+	if len(in) == 0 || string(in) == "null" {
+		in = json.RawMessage("{}")
+	}
+	if err := ValidateJSON(t.schema, in); err != nil {
+		return ToolResult{}, err
+	}
+	// Defaults are set on a fresh value each call so pointer and slice
+	// defaults are never shared; the tags were checked by structSchema.
+	var v In
+	_ = setDefaults(reflect.ValueOf(&v).Elem())
+	if err := json.Unmarshal(in, &v); err != nil {
+		return ToolResult{}, err
+	}
+	return t.handler(ctx, v)
+}
+
+// structSchema builds an object schema from a struct type.
+func structSchema(typ reflect.Type) (map[string]any, error) {
+	props := map[string]any{}
+	var required []string
+	if err := addFields(typ, props, &required); err != nil {
+		return nil, err
+	}
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s, nil
+}
+
+func addFields(typ reflect.Type, props map[string]any, required *[]string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := addFields(ft, props, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s, err := typeSchema(f.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		if d := f.Tag.Get("desc"); d != "" {
+			s["description"] = d
+		}
+		if e := f.Tag.Get("enum"); e != "" {
+			var vals []any
+			for _, part := range strings.Split(e, ",") {
+				v, err := tagValue(f.Type, strings.TrimSpace(part))
+				if err != nil {
+					return fmt.Errorf("field %s: enum: %w", f.Name, err)
+				}
+				vals = append(vals, v)
+			}
+			s["enum"] = vals
+		}
+		if d, ok := f.Tag.Lookup("default"); ok {
+			v, err := tagValue(f.Type, d)
+			if err != nil {
+				return fmt.Errorf("field %s: default: %w", f.Name, err)
+			}
+			s["default"] = v
+		}
+		for _, key := range []string{"minimum", "maximum"} {
+			if b := f.Tag.Get(key); b != "" {
+				n, err := strconv.ParseFloat(b, 64)
+				if err != nil {
+					return fmt.Errorf("field %s: %s: %w", f.Name, key, err)
+				}
+				s[key] = n
+			}
+		}
+		if f.Tag.Get("required") == "true" {
+			*required = append(*required, name)
+		}
+		props[name] = s
+	}
+	return nil
+}
+
+// jsonFieldName returns the JSON name of f ("" for an untagged field) and
+// whether encoding/json would use the field at all.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if !f.IsExported() && !f.Anonymous {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, true
+}
+
+func typeSchema(typ reflect.Type) (map[string]any, error) {
+	if typ == rawMessageType {
+		return map[string]any{}, nil
+	}
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return typeSchema(typ.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key must be a string, not %v", typ.Key())
+		}
+		values, err := typeSchema(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return structSchema(typ)
+	}
+	return nil, fmt.Errorf("unsupported type %v", typ)
+}
+
+// tagValue parses a default or enum tag value as JSON for typ. A bare
+// string is accepted for string fields.
+func tagValue(typ reflect.Type, s string) (any, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.String && !strings.HasPrefix(s, `"`) {
+		return reflect.ValueOf(s).Convert(typ).Interface(), nil
+	}
+	p := reflect.New(typ)
+	if err := json.Unmarshal([]byte(s), p.Interface()); err != nil {
+		return nil, err
+	}
+	return p.Elem().Interface(), nil
+}
+
+// setDefaults fills the fields of v that have a default tag, including in
+// nested structs.
+func setDefaults(v reflect.Value) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if _, ok := jsonFieldName(f); !ok || !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if d, ok := f.Tag.Lookup("default"); ok {
+			val, err := tagValue(f.Type, d)
+			if err != nil {
+				return fmt.Errorf("field %s: default: %w", f.Name, err)
+			}
+			rv := reflect.ValueOf(val)
+			if f.Type.Kind() == reflect.Pointer {
+				p := reflect.New(f.Type.Elem())
+				p.Elem().Set(rv)
+				rv = p
+			}
+			fv.Set(rv)
+			continue
+		}
+		if f.Type.Kind() == reflect.Struct {
+			if err := setDefaults(fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
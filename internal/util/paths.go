@@ -2,6 +2,7 @@ package util
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,48 +11,96 @@ import (
 var ErrPathOutsideRoot = errors.New("path outside repo root")
 
 // ResolvePathWithinRoot resolves a relative path under root and rejects traversal.
-// If the resolved path exists, we also resolve symlinks and ensure it still stays under root.
+// Symlinks in the existing part of the path are resolved and the result must
+// still be under root, so a link cannot lead a new file outside it.
 func ResolvePathWithinRoot(root string, rel string) (string, error) {
+	absRoot, absTarget, err := lexicalTarget(root, rel)
+	if err != nil {
+		return "", err
+	}
+	if _, err := realTarget(absRoot, absTarget); err != nil {
+		return "", err
+	}
+	return absTarget, nil
+}
+
+// RealRelPath is the path of rel relative to root after resolving symlinks
+// in its existing part (e.g. "link/hooks/x" for a link to .git gives
+// ".git/hooks/x"). It fails like ResolvePathWithinRoot.
+func RealRelPath(root string, rel string) (string, error) {
+	absRoot, absTarget, err := lexicalTarget(root, rel)
+	if err != nil {
+		return "", err
+	}
+	real, err := realTarget(absRoot, absTarget)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(realRoot(absRoot), real)
+}
+
+func lexicalTarget(root string, rel string) (string, string, error) {
 	if rel == "" {
-		return "", errors.New("empty path")
+		return "", "", errors.New("empty path")
 	}
 	if filepath.IsAbs(rel) {
-		return "", ErrPathOutsideRoot
+		return "", "", ErrPathOutsideRoot
 	}
 
 	cleanRel := filepath.Clean(rel)
 	if cleanRel == "." || cleanRel == ".." || strings.HasPrefix(cleanRel, ".."+string(os.PathSeparator)) {
-		return "", ErrPathOutsideRoot
+		return "", "", ErrPathOutsideRoot
 	}
 
 	absRoot, err := filepath.Abs(root)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	absTarget, err := filepath.Abs(filepath.Join(absRoot, cleanRel))
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	if !isWithin(absRoot, absTarget) {
-		return "", ErrPathOutsideRoot
+		return "", "", ErrPathOutsideRoot
+	}
+	return absRoot, absTarget, nil
+}
+
+func realRoot(absRoot string) string {
+	if r, err := filepath.EvalSymlinks(absRoot); err == nil {
+		return r
 	}
+	return absRoot
+}
 
-	// Best-effort symlink escape protection for existing paths.
-	if _, err := os.Stat(absTarget); err == nil {
-		rootReal, rerr := filepath.EvalSymlinks(absRoot)
-		if rerr != nil {
-			rootReal = absRoot
+// realTarget resolves symlinks in the deepest existing ancestor of
+// absTarget, appends the rest and checks the result is under the real root.
+func realTarget(absRoot string, absTarget string) (string, error) {
+	dir, rest := absTarget, ""
+	for {
+		r, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			real := filepath.Join(r, rest)
+			if !isWithin(realRoot(absRoot), real) {
+				return "", ErrPathOutsideRoot
+			}
+			return real, nil
 		}
-		targetReal, terr := filepath.EvalSymlinks(absTarget)
-		if terr != nil {
-			targetReal = absTarget
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
 		}
-		if !isWithin(rootReal, targetReal) {
-			return "", ErrPathOutsideRoot
+		if _, lerr := os.Lstat(dir); lerr == nil {
+			// A dangling symlink: writing through it would create its
+			// target, wherever that is.
+			return "", fmt.Errorf("%s is a dangling symlink", filepath.Base(dir))
 		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return absTarget, nil
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
 	}
-
-	return absTarget, nil
 }
 
 func isWithin(root string, target string) bool {